@@ -9,8 +9,10 @@ import (
 	"html/template"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	ppath "path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -80,6 +82,22 @@ type Air struct {
 	// Default value: nil
 	HostWhitelist []string `mapstructure:"host_whitelist"`
 
+	// TrustedProxies is the list of the IP addresses or the CIDR ranges of
+	// the proxies trusted by the server of the current web application.
+	//
+	// The Forwarded header, the X-Forwarded-For header, the
+	// X-Forwarded-Proto header and the X-Forwarded-Host header are only
+	// honored when the `Request.RemoteAddress` is in the list. The chain of
+	// the forwarding headers is walked from right to left, and the first
+	// hop that is not in the list is considered as the client. See the
+	// `Request.ClientAddress`, the `Request.Scheme` and the
+	// `Request.Authority`.
+	//
+	// Example: []string{"127.0.0.1", "10.0.0.0/8", "fd00::/8"}
+	//
+	// Default value: nil
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
 	// ReadTimeout is the maximum duration the server of the current web
 	// application reads a request entirely, including the body part.
 	//
@@ -124,6 +142,52 @@ type Air struct {
 	// Default value: 1048576
 	MaxHeaderBytes int `mapstructure:"max_header_bytes"`
 
+	// MaxRequestBodyBytes is the maximum number of bytes the server of the
+	// current web application will read from a request body.
+	//
+	// Once a request body exceeds the `MaxRequestBodyBytes`, reading it
+	// returns an error and the status of the response is set to 413, so the
+	// error will be handled by the `ErrorHandler` with the correct status.
+	// A request whose Content-Length header already exceeds the limit is
+	// rejected without reading its body at all.
+	//
+	// The `MaxRequestBodyBytes` can be overridden by the
+	// `Group.MaxRequestBodyBytes` and the `Route.MaxRequestBodyBytes`,
+	// which take effect once a request is routed. So the bytes read by the
+	// `Pregases` are limited by the `MaxRequestBodyBytes` only, and are
+	// counted against the overriding limit after routing. If it is zero or
+	// negative, there is no limit.
+	//
+	// Default value: 0
+	MaxRequestBodyBytes int64 `mapstructure:"max_request_body_bytes"`
+
+	// MultipartFormMaxMemoryBytes is the maximum number of bytes of the
+	// non-file parts of a request multipart form stored in memory. The
+	// rest parts will be stored on disk in temporary files.
+	//
+	// Default value: 33554432
+	MultipartFormMaxMemoryBytes int64 `mapstructure:"multipart_form_max_memory_bytes"`
+
+	// MultipartFormMaxFiles is the maximum number of files a request
+	// multipart form can contain.
+	//
+	// A request multipart form that contains more files is rejected with
+	// status 413. If the `MultipartFormMaxFiles` is zero or negative, there
+	// is no limit.
+	//
+	// Default value: 0
+	MultipartFormMaxFiles int `mapstructure:"multipart_form_max_files"`
+
+	// MultipartFormMaxFields is the maximum number of non-file fields a
+	// request multipart form can contain.
+	//
+	// A request multipart form that contains more fields is rejected with
+	// status 413. If the `MultipartFormMaxFields` is zero or negative,
+	// there is no limit.
+	//
+	// Default value: 0
+	MultipartFormMaxFields int `mapstructure:"multipart_form_max_fields"`
+
 	// TLSCertFile is the path to the TLS certificate file used when
 	// starting the server of the current web application.
 	//
@@ -213,6 +277,31 @@ type Air struct {
 	// Default value: nil
 	WebSocketSubprotocols []string `mapstructure:"websocket_subprotocols"`
 
+	// RouterRedirectTrailingSlash indicates whether the router of the
+	// current web application redirects the requests that have no matching
+	// routes to the paths with (or without) the trailing slash if there are
+	// matching routes for them.
+	//
+	// The GET and HEAD requests will be redirected with the 301 status
+	// code, others with the 308 status code. The query part is always kept.
+	//
+	// Default value: false
+	RouterRedirectTrailingSlash bool `mapstructure:"router_redirect_trailing_slash"`
+
+	// RouterRedirectFixedPath indicates whether the router of the current
+	// web application redirects the requests that have no matching routes
+	// to the fixed paths if there are matching routes for them.
+	//
+	// A fixed path is the cleaned path (see the `path.Clean`) that matches
+	// a registered route case-insensitively. It may also get the trailing
+	// slash toggled if the `RouterRedirectTrailingSlash` is true.
+	//
+	// The GET and HEAD requests will be redirected with the 301 status
+	// code, others with the 308 status code. The query part is always kept.
+	//
+	// Default value: false
+	RouterRedirectFixedPath bool `mapstructure:"router_redirect_fixed_path"`
+
 	// Pregases is the `Gas` chain stack of the current web application
 	// that performs before routing.
 	//
@@ -244,7 +333,11 @@ type Air struct {
 	//
 	// The `MethodNotAllowedHandler` is never nil because the router of the
 	// current web application will use it as the default `Handler` when it
-	// finds a matching route but its method is not registered.
+	// finds a matching route but its method is not registered. The Allow
+	// header of the response is always set before it is called.
+	//
+	// Note that the OPTIONS requests will be answered automatically with
+	// the Allow header if there is no OPTIONS route registered for them.
 	//
 	// Default value: `DefaultMethodNotAllowedHandler`
 	MethodNotAllowedHandler func(*Request, *Response) error `mapstructure:"-"`
@@ -458,6 +551,8 @@ type Air struct {
 	server                       *server
 	router                       *router
 	binder                       *binder
+	validator                    *validator
+	codecRegistry                *codecRegistry
 	minifier                     *minifier
 	renderer                     *renderer
 	coffer                       *coffer
@@ -481,14 +576,15 @@ var Default = New()
 // keeps everything working.
 func New() *Air {
 	a := &Air{
-		AppName:                 "air",
-		Address:                 ":8080",
-		MaxHeaderBytes:          1 << 20,
-		ACMEDirectoryURL:        "https://acme-v01.api.letsencrypt.org/directory",
-		ACMECertRoot:            "acme-certs",
-		NotFoundHandler:         DefaultNotFoundHandler,
-		MethodNotAllowedHandler: DefaultMethodNotAllowedHandler,
-		ErrorHandler:            DefaultErrorHandler,
+		AppName:                     "air",
+		Address:                     ":8080",
+		MaxHeaderBytes:              1 << 20,
+		MultipartFormMaxMemoryBytes: 32 << 20,
+		ACMEDirectoryURL:            "https://acme-v01.api.letsencrypt.org/directory",
+		ACMECertRoot:                "acme-certs",
+		NotFoundHandler:             DefaultNotFoundHandler,
+		MethodNotAllowedHandler:     DefaultMethodNotAllowedHandler,
+		ErrorHandler:                DefaultErrorHandler,
 		MinifierMIMETypes: []string{
 			"text/html",
 			"text/css",
@@ -540,6 +636,8 @@ func New() *Air {
 	a.server = newServer(a)
 	a.router = newRouter(a)
 	a.binder = newBinder(a)
+	a.validator = newValidator(a)
+	a.codecRegistry = newCodecRegistry(a)
 	a.minifier = newMinifier(a)
 	a.renderer = newRenderer(a)
 	a.coffer = newCoffer(a)
@@ -564,57 +662,69 @@ func New() *Air {
 }
 
 // GET registers a new GET route for the path with the matching h in the router
-// of the a with the optional route-level gases.
-func (a *Air) GET(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodGet, path, h, gases...)
+// of the a with the optional route-level gases, and returns the registered
+// `Route`.
+//
+// The GET route also serves the HEAD requests for the path unless there is a
+// HEAD route registered for it.
+func (a *Air) GET(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodGet, path, h, gases...)
 }
 
 // HEAD registers a new HEAD route for the path with the matching h in the
-// router of the a with the optional route-level gases.
-func (a *Air) HEAD(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodHead, path, h, gases...)
+// router of the a with the optional route-level gases, and returns the
+// registered `Route`.
+func (a *Air) HEAD(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodHead, path, h, gases...)
 }
 
 // POST registers a new POST route for the path with the matching h in the
-// router of the a with the optional route-level gases.
-func (a *Air) POST(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodPost, path, h, gases...)
+// router of the a with the optional route-level gases, and returns the
+// registered `Route`.
+func (a *Air) POST(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodPost, path, h, gases...)
 }
 
 // PUT registers a new PUT route for the path with the matching h in the router
-// of the a with the optional route-level gases.
-func (a *Air) PUT(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodPut, path, h, gases...)
+// of the a with the optional route-level gases, and returns the registered
+// `Route`.
+func (a *Air) PUT(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodPut, path, h, gases...)
 }
 
 // PATCH registers a new PATCH route for the path with the matching h in the
-// router of the a with the optional route-level gases.
-func (a *Air) PATCH(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodPatch, path, h, gases...)
+// router of the a with the optional route-level gases, and returns the
+// registered `Route`.
+func (a *Air) PATCH(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodPatch, path, h, gases...)
 }
 
 // DELETE registers a new DELETE route for the path with the matching h in the
-// router of the a with the optional route-level gases.
-func (a *Air) DELETE(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodDelete, path, h, gases...)
+// router of the a with the optional route-level gases, and returns the
+// registered `Route`.
+func (a *Air) DELETE(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodDelete, path, h, gases...)
 }
 
 // CONNECT registers a new CONNECT route for the path with the matching h in the
-// router of the a with the optional route-level gases.
-func (a *Air) CONNECT(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodConnect, path, h, gases...)
+// router of the a with the optional route-level gases, and returns the
+// registered `Route`.
+func (a *Air) CONNECT(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodConnect, path, h, gases...)
 }
 
 // OPTIONS registers a new OPTIONS route for the path with the matching h in the
-// router of the a with the optional route-level gases.
-func (a *Air) OPTIONS(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodOptions, path, h, gases...)
+// router of the a with the optional route-level gases, and returns the
+// registered `Route`.
+func (a *Air) OPTIONS(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodOptions, path, h, gases...)
 }
 
 // TRACE registers a new TRACE route for the path with the matching h in the
-// router of the a with the optional route-level gases.
-func (a *Air) TRACE(path string, h Handler, gases ...Gas) {
-	a.router.register(http.MethodTrace, path, h, gases...)
+// router of the a with the optional route-level gases, and returns the
+// registered `Route`.
+func (a *Air) TRACE(path string, h Handler, gases ...Gas) *Route {
+	return a.router.register(http.MethodTrace, path, h, gases...)
 }
 
 // BATCH registers a batch of routes for the methods and the path with the
@@ -623,7 +733,40 @@ func (a *Air) TRACE(path string, h Handler, gases ...Gas) {
 // The methods must either be nil (means all) or consists of one or more of the
 // "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS" and
 // "TRACE". Invalid methods will be silently ignored.
-func (a *Air) BATCH(methods []string, path string, h Handler, gases ...Gas) {
+//
+// It returns the registered routes.
+func (a *Air) BATCH(
+	methods []string,
+	path string,
+	h Handler,
+	gases ...Gas,
+) []*Route {
+	return a.batch("", methods, path, h, gases...)
+}
+
+// FILE registers a new GET route and a new HEAD route with the path in the
+// router of the a to serve a static file with the filename and the optional
+// route-level gases. It returns the registered routes.
+func (a *Air) FILE(path, filename string, gases ...Gas) []*Route {
+	return a.file("", path, filename, gases...)
+}
+
+// FILES registers a new GET route and a new HEAD route with the path prefix in
+// the router of the a to serve the static files from the root with the optional
+// route-level gases. It returns the registered routes.
+func (a *Air) FILES(prefix, root string, gases ...Gas) []*Route {
+	return a.files("", prefix, root, gases...)
+}
+
+// batch is just like the `BATCH` of the a, but the routes will be registered
+// for the host.
+func (a *Air) batch(
+	host string,
+	methods []string,
+	path string,
+	h Handler,
+	gases ...Gas,
+) []*Route {
 	if methods == nil {
 		methods = []string{
 			http.MethodGet,
@@ -638,50 +781,55 @@ func (a *Air) BATCH(methods []string, path string, h Handler, gases ...Gas) {
 		}
 	}
 
+	rs := make([]*Route, 0, len(methods))
 	for _, m := range methods {
 		switch m {
-		case http.MethodGet:
-			a.GET(path, h, gases...)
-		case http.MethodHead:
-			a.HEAD(path, h, gases...)
-		case http.MethodPost:
-			a.POST(path, h, gases...)
-		case http.MethodPut:
-			a.PUT(path, h, gases...)
-		case http.MethodPatch:
-			a.PATCH(path, h, gases...)
-		case http.MethodDelete:
-			a.DELETE(path, h, gases...)
-		case http.MethodConnect:
-			a.CONNECT(path, h, gases...)
-		case http.MethodOptions:
-			a.OPTIONS(path, h, gases...)
-		case http.MethodTrace:
-			a.TRACE(path, h, gases...)
+		case http.MethodGet,
+			http.MethodHead,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+			http.MethodConnect,
+			http.MethodOptions,
+			http.MethodTrace:
+			rs = append(rs, a.router.registerHost(
+				host,
+				m,
+				path,
+				h,
+				gases...,
+			))
 		}
 	}
+
+	return rs
 }
 
-// FILE registers a new GET route and a new HEAD route with the path in the
-// router of the a to serve a static file with the filename and the optional
-// route-level gases.
-func (a *Air) FILE(path, filename string, gases ...Gas) {
+// file is just like the `FILE` of the a, but the routes will be registered for
+// the host.
+func (a *Air) file(host, path, filename string, gases ...Gas) []*Route {
 	h := func(req *Request, res *Response) error {
 		err := res.WriteFile(filename)
 		if os.IsNotExist(err) {
-			return a.NotFoundHandler(req, res)
+			return a.notFoundHandler(req)(req, res)
 		}
 
 		return err
 	}
 
-	a.BATCH([]string{http.MethodGet, http.MethodHead}, path, h, gases...)
+	return a.batch(
+		host,
+		[]string{http.MethodGet, http.MethodHead},
+		path,
+		h,
+		gases...,
+	)
 }
 
-// FILES registers a new GET route and a new HEAD route with the path prefix in
-// the router of the a to serve the static files from the root with the optional
-// route-level gases.
-func (a *Air) FILES(prefix, root string, gases ...Gas) {
+// files is just like the `FILES` of the a, but the routes will be registered
+// for the host.
+func (a *Air) files(host, prefix, root string, gases ...Gas) []*Route {
 	if strings.HasSuffix(prefix, "/") {
 		prefix += "*"
 	} else {
@@ -695,7 +843,7 @@ func (a *Air) FILES(prefix, root string, gases ...Gas) {
 	h := func(req *Request, res *Response) error {
 		p := req.Param("*")
 		if p == nil {
-			return a.NotFoundHandler(req, res)
+			return a.notFoundHandler(req)(req, res)
 		}
 
 		path := p.Value().String()
@@ -704,23 +852,32 @@ func (a *Air) FILES(prefix, root string, gases ...Gas) {
 
 		err := res.WriteFile(filepath.Join(root, path))
 		if os.IsNotExist(err) {
-			return a.NotFoundHandler(req, res)
+			return a.notFoundHandler(req)(req, res)
 		}
 
 		return err
 	}
 
-	a.BATCH([]string{http.MethodGet, http.MethodHead}, prefix, h, gases...)
+	return a.batch(
+		host,
+		[]string{http.MethodGet, http.MethodHead},
+		prefix,
+		h,
+		gases...,
+	)
 }
 
 // Group returns a new instance of the `Group` with the path prefix and the
 // optional group-level gases that inherited from the a.
 func (a *Air) Group(prefix string, gases ...Gas) *Group {
-	return &Group{
+	g := &Group{
 		Air:    a,
 		Prefix: prefix,
 		Gases:  gases,
 	}
+	a.router.registerGroup(g)
+
+	return g
 }
 
 // Serve starts the server of the a.
@@ -772,6 +929,332 @@ func (a *Air) Shutdown(timeout time.Duration) error {
 	return a.server.shutdown(timeout)
 }
 
+// Host returns a new instance of the `Group` with the host pattern and the
+// optional group-level gases that inherited from the a. See the `Group.Host`
+// for the syntax of the host pattern.
+func (a *Air) Host(host string, gases ...Gas) *Group {
+	g := &Group{
+		Air:   a,
+		Host:  host,
+		Gases: gases,
+	}
+	a.router.registerGroup(g)
+
+	return g
+}
+
+// Mount mounts the child under the path prefix in the router of the a with the
+// optional route-level gases. It returns the registered routes.
+//
+// All requests that match the prefix will be served by the child as a fully
+// independent web application (its own `Pregases`, `Gases`, `NotFoundHandler`,
+// `ErrorHandler`, renderer, i18n and so on will be used), with the prefix
+// stripped from their `Request.Path`, which will be restored after the child
+// has done. The route params of the prefix are passed to the child, and are
+// overridden by the ones of the child with the same names. The server-related
+// configuration of the child is ignored, and the request body limits of the
+// child never exceed the one in effect where the child is mounted.
+func (a *Air) Mount(prefix string, child *Air, gases ...Gas) []*Route {
+	return a.mount("", prefix, child, gases...)
+}
+
+// Routes returns all routes registered in the router of the a in the order of
+// registration.
+func (a *Air) Routes() []*Route {
+	return a.router.routesSnapshot()
+}
+
+// URL returns a URL path built from the first route named with the name in the
+// router of the a by filling its params with the params. It returns "" if there
+// is no route named with the name, or if any of the required params of the
+// route is missing.
+//
+// The param values will be properly escaped, and the missing optional ones
+// will be omitted.
+func (a *Air) URL(name string, params map[string]interface{}) string {
+	return a.router.url(name, params)
+}
+
+// RemoveRoute removes the rt from the router of the a. It does nothing if the
+// rt is not registered in the router of the a.
+//
+// It is safe to call the `RemoveRoute` while the a is serving, the requests
+// that have already been routed will not be affected.
+func (a *Air) RemoveRoute(rt *Route) {
+	a.router.remove(rt)
+}
+
+// ReplaceRouteHandler replaces the handler of the rt in the router of the a
+// with the h, the route-level gases of the rt will be kept. It does nothing if
+// the rt is not registered in the router of the a.
+//
+// It is safe to call the `ReplaceRouteHandler` while the a is serving, the
+// requests that have already been routed will not be affected.
+func (a *Air) ReplaceRouteHandler(rt *Route, h Handler) {
+	a.router.replaceHandler(rt, h)
+}
+
+// RegisterCodec registers the c for the mimeType (e.g. "application/cbor") into
+// the codec registry of the a. It replaces the registered `Codec` for the
+// mimeType if there is one, and removes it if the c is nil. The mimeType is
+// case-insensitive.
+//
+// The registered codecs are used by the `Request.Bind`, the
+// `Response.WriteEncoded` and the `Response.WriteNegotiated`. The codecs for
+// the "application/json", the "application/xml", the "application/msgpack",
+// the "application/protobuf", the "application/yaml" and the
+// "application/toml" are registered by default in order, the order of the
+// registration is the order of preference in the `Response.WriteNegotiated`.
+//
+// It is safe to call the `RegisterCodec` while the a is serving.
+func (a *Air) RegisterCodec(mimeType string, c Codec) {
+	a.codecRegistry.register(mimeType, c)
+}
+
+// Codec returns the registered `Codec` for the mimeType in the codec registry
+// of the a. It returns nil if not found.
+func (a *Air) Codec(mimeType string) Codec {
+	return a.codecRegistry.codec(mimeType)
+}
+
+// mount is just like the `Mount` of the a, but the routes will be registered
+// for the host.
+func (a *Air) mount(host, prefix string, child *Air, gases ...Gas) []*Route {
+	if child == nil {
+		panic("air: mounted air cannot be nil")
+	} else if child == a {
+		panic("air: air cannot be mounted on itself")
+	}
+
+	if prefix == "" {
+		prefix = "/"
+	}
+
+	p, _ := splitRouteParamConstraints(prefix)
+	p = ppath.Clean(p)
+
+	sc := strings.Count(p, "/") // Segment count
+	if p == "/" {
+		sc = 0
+	}
+
+	var wrs []*Route // Wildcard routes
+	h := func(req *Request, res *Response) error {
+		cp, q := splitPathQuery(req.Path)
+		for i := 0; i < sc; i++ {
+			cp = strings.TrimLeft(cp, "/")
+			if j := strings.IndexByte(cp, '/'); j >= 0 {
+				cp = cp[j:]
+			} else {
+				cp = ""
+			}
+		}
+
+		if cp == "" {
+			cp = "/"
+		}
+
+		if q != "" {
+			cp += "?" + q
+		}
+
+		ps := req.routeParams()
+		for _, rt := range wrs {
+			if rt != req.route {
+				continue
+			}
+
+			// The last value of the "*" is the rest of the path
+			// matched by the prefix+"/*", which is not a route
+			// param of the prefix.
+			for i, p := range ps {
+				if p.Name != "*" {
+					continue
+				}
+
+				p.Values = p.Values[:len(p.Values)-1]
+				if len(p.Values) == 0 {
+					ps = append(ps[:i:i], ps[i+1:]...)
+				}
+
+				break
+			}
+
+			break
+		}
+
+		pa := req.Air
+		pp := req.Path
+		pps := req.params
+		prt := req.route
+		prp := req.routePattern
+		prpo := req.parseRouteParamsOnce
+		popo := req.parseOtherParamsOnce
+		pope := req.otherParamsError
+		pls := req.localizedString
+		pmmbb := req.mountMaxBodyBytes
+		defer func() {
+			if req.routeParamValues != nil {
+				child.router.routeParamValuesPool.Put(
+					req.routeParamValues,
+				)
+			}
+
+			req.Air = pa
+			req.Path = pp
+			req.params = pps
+			req.route = prt
+			req.routePattern = mountedRoutePattern(
+				prp,
+				req.routePattern,
+			)
+			req.routeParamNames = nil
+			req.routeParamValues = nil
+			req.parseRouteParamsOnce = prpo
+			req.parseOtherParamsOnce = popo
+			req.otherParamsError = pope
+			req.localizedString = pls
+			req.mountMaxBodyBytes = pmmbb
+			res.Air = pa
+		}()
+
+		req.mountMaxBodyBytes = pa.maxRequestBodyBytes(req)
+		req.Air = child
+		req.Path = cp
+		req.params = ps
+		req.route = nil
+		req.routePattern = ""
+		req.routeParamNames = nil
+		req.routeParamValues = nil
+		req.parseRouteParamsOnce = &sync.Once{}
+		req.parseOtherParamsOnce = &sync.Once{}
+		req.otherParamsError = nil
+		req.localizedString = nil
+		res.Air = child
+
+		child.serveMounted(req, res)
+
+		return nil
+	}
+
+	rs := a.batch(host, nil, prefix, h, gases...)
+	wrs = a.batch(host, nil, prefix+"/*", h, gases...)
+
+	return append(rs, wrs...)
+}
+
+// notFoundHandler returns the not found handler for the req. It is the
+// `Group.NotFoundHandler` of the closest group that the req falls under, or
+// the `NotFoundHandler` of the a if there is no such group.
+func (a *Air) notFoundHandler(req *Request) func(*Request, *Response) error {
+	if g := a.router.closestGroup(req, func(g *Group) bool {
+		return g.NotFoundHandler != nil
+	}); g != nil {
+		return g.NotFoundHandler
+	}
+
+	return a.NotFoundHandler
+}
+
+// methodNotAllowedHandler is just like the `notFoundHandler` of the a, but for
+// the method not allowed handler.
+func (a *Air) methodNotAllowedHandler(
+	req *Request,
+) func(*Request, *Response) error {
+	if g := a.router.closestGroup(req, func(g *Group) bool {
+		return g.MethodNotAllowedHandler != nil
+	}); g != nil {
+		return g.MethodNotAllowedHandler
+	}
+
+	return a.MethodNotAllowedHandler
+}
+
+// errorHandler is just like the `notFoundHandler` of the a, but for the error
+// handler.
+func (a *Air) errorHandler(req *Request) func(error, *Request, *Response) {
+	if g := a.router.closestGroup(req, func(g *Group) bool {
+		return g.ErrorHandler != nil
+	}); g != nil {
+		return g.ErrorHandler
+	}
+
+	return a.ErrorHandler
+}
+
+// maxRequestBodyBytes returns the maximum number of bytes of the body of the
+// req. The `Route.MaxRequestBodyBytes` of the matched route of the req wins,
+// then the `Group.MaxRequestBodyBytes` of the closest group of the req, and
+// finally the `MaxRequestBodyBytes` of the a. If the a is mounted, the result
+// never exceeds the limit in effect where the a is mounted.
+func (a *Air) maxRequestBodyBytes(req *Request) int64 {
+	mrbb := a.MaxRequestBodyBytes
+	if req.route != nil && req.route.MaxRequestBodyBytes != 0 {
+		mrbb = req.route.MaxRequestBodyBytes
+	} else if g := a.router.closestGroup(req, func(g *Group) bool {
+		return g.MaxRequestBodyBytes != 0
+	}); g != nil {
+		mrbb = g.MaxRequestBodyBytes
+	}
+
+	if mmbb := req.mountMaxBodyBytes; mmbb > 0 &&
+		(mrbb <= 0 || mrbb > mmbb) {
+		mrbb = mmbb
+	}
+
+	return mrbb
+}
+
+// mountedRoutePattern returns the route pattern of a request served by a
+// mounted web application. The mrp is the pattern of the route that the mounted
+// web application is mounted on, and the rp is the pattern of the route of the
+// mounted web application that the request matches.
+func mountedRoutePattern(mrp, rp string) string {
+	if rp == "" {
+		return mrp
+	}
+
+	p := strings.TrimSuffix(strings.TrimSuffix(mrp, "/*"), "/")
+	if rp == "/" && p != "" {
+		return p
+	}
+
+	return p + rp
+}
+
+// serveMounted serves the req and the res that have been handed over to the a
+// as a mounted web application.
+func (a *Air) serveMounted(req *Request, res *Response) {
+	h := func(req *Request, res *Response) error {
+		rh := a.router.route(req)
+		rh = a.router.wrapGroupGases(req, rh)
+		h := func(req *Request, res *Response) error {
+			err := rh(req, res)
+			if err == nil || res.Written {
+				return err
+			} else if res.Status < http.StatusBadRequest {
+				res.Status = http.StatusInternalServerError
+			}
+
+			return err
+		}
+
+		for i := len(a.Gases) - 1; i >= 0; i-- {
+			h = a.Gases[i](h)
+		}
+
+		return h(req, res)
+	}
+
+	for i := len(a.Pregases) - 1; i >= 0; i-- {
+		h = a.Pregases[i](h)
+	}
+
+	if err := h(req, res); err != nil {
+		a.errorHandler(req)(err, req, res)
+	}
+}
+
 // Handler defines a function to serve requests.
 type Handler func(*Request, *Response) error
 
@@ -798,14 +1281,27 @@ func DefaultMethodNotAllowedHandler(req *Request, res *Response) error {
 }
 
 // DefaultErrorHandler is the default centralized error handler for the server.
+//
+// When not in debug mode, the `*BindError`s are rendered without their
+// underlying causes, so that the Go-internal messages are never shown to the
+// clients.
 func DefaultErrorHandler(err error, req *Request, res *Response) {
 	if res.ContentLength > 0 {
 		return
 	}
 
 	m := err.Error()
-	if !req.Air.DebugMode && res.Status == http.StatusInternalServerError {
-		m = http.StatusText(res.Status)
+	if !req.Air.DebugMode {
+		var be *BindError
+		if res.Status == http.StatusInternalServerError {
+			m = http.StatusText(res.Status)
+		} else if errors.As(err, &be) {
+			if res.Status == http.StatusBadRequest {
+				m = be.message()
+			} else {
+				m = http.StatusText(res.Status)
+			}
+		}
 	}
 
 	res.WriteString(m)
@@ -871,6 +1367,36 @@ func (elw *errorLogWriter) Write(b []byte) (int, error) {
 	return len(s), log.Output(2, s)
 }
 
+// trustedProxy reports whether the address (with or without port) is in the
+// `TrustedProxies` of the a.
+func (a *Air) trustedProxy(address string) bool {
+	if len(a.TrustedProxies) == 0 {
+		return false
+	}
+
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		address = h
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, tp := range a.TrustedProxies {
+		if strings.Contains(tp, "/") {
+			if _, n, err := net.ParseCIDR(tp); err == nil &&
+				n.Contains(ip) {
+				return true
+			}
+		} else if tip := net.ParseIP(tp); tip != nil && tip.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // stringSliceContains reports whether the ss contains the s.
 func stringSliceContains(ss []string, s string) bool {
 	for _, v := range ss {
@@ -65,3 +65,184 @@ func fakeRRCycle(
 
 	return req, res, rec
 }
+
+func TestMountedRoutePattern(t *testing.T) {
+	assert.Equal(t, "/foo/*", mountedRoutePattern("/foo/*", ""))
+	assert.Equal(t, "/foo", mountedRoutePattern("/foo", "/"))
+	assert.Equal(t, "/foo", mountedRoutePattern("/foo/*", "/"))
+	assert.Equal(t, "/foo/:bar", mountedRoutePattern("/foo/*", "/:bar"))
+	assert.Equal(t, "/", mountedRoutePattern("/", "/"))
+	assert.Equal(t, "/", mountedRoutePattern("/*", "/"))
+	assert.Equal(t, "/:bar", mountedRoutePattern("/*", "/:bar"))
+}
+
+func TestAirMount(t *testing.T) {
+	a := New()
+	child := New()
+
+	assert.PanicsWithValue(t, "air: mounted air cannot be nil", func() {
+		a.Mount("/admin", nil)
+	})
+
+	assert.PanicsWithValue(
+		t,
+		"air: air cannot be mounted on itself",
+		func() {
+			a.Mount("/admin", a)
+		},
+	)
+
+	child.NotFoundHandler = func(req *Request, res *Response) error {
+		res.Status = http.StatusNotFound
+		return res.WriteString("Child Not Found")
+	}
+
+	child.Gases = []Gas{
+		func(next Handler) Handler {
+			return func(req *Request, res *Response) error {
+				res.Header.Set("X-Air", "child")
+				return next(req, res)
+			}
+		},
+	}
+
+	child.GET("/", func(req *Request, res *Response) error {
+		return res.WriteString("Matched [GET /] of child " + req.Path)
+	})
+
+	child.GET("/users/:id", func(req *Request, res *Response) error {
+		assert.Equal(t, child, req.Air)
+		assert.Equal(t, child, res.Air)
+		assert.Equal(
+			t,
+			"foo",
+			req.RouteParam("tenant").String(),
+		)
+		return res.WriteString(
+			"Matched [GET /users/:id] of child " +
+				req.Param("id").Value().String(),
+		)
+	})
+
+	rs := a.Mount("/tenants/:tenant/admin", child)
+	assert.Len(t, rs, 18)
+
+	rp := ""
+
+	a.Gases = []Gas{
+		func(next Handler) Handler {
+			return func(req *Request, res *Response) error {
+				err := next(req, res)
+				assert.Equal(t, a, req.Air)
+				assert.Equal(t, a, res.Air)
+				assert.Equal(
+					t,
+					"foo",
+					req.Param("tenant").Value().String(),
+				)
+				rp = req.RoutePattern()
+				return err
+			}
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tenants/foo/admin", nil)
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "child", rec.Header().Get("X-Air"))
+	assert.Equal(t, "Matched [GET /] of child /", rec.Body.String())
+	assert.Equal(
+		t,
+		"/tenants/:tenant/admin",
+		rp,
+	)
+
+	req = httptest.NewRequest(
+		http.MethodGet,
+		"/tenants/foo/admin/?bar=baz",
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(
+		t,
+		"Matched [GET /] of child /?bar=baz",
+		rec.Body.String(),
+	)
+
+	req = httptest.NewRequest(
+		http.MethodGet,
+		"/tenants/foo/admin/users/1",
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(
+		t,
+		"Matched [GET /users/:id] of child 1",
+		rec.Body.String(),
+	)
+	assert.Equal(
+		t,
+		"/tenants/:tenant/admin/users/:id",
+		rp,
+	)
+
+	req = httptest.NewRequest(
+		http.MethodGet,
+		"/tenants/foo/admin/bar",
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "Child Not Found", rec.Body.String())
+
+	child = New()
+	child.GET("/:id", func(req *Request, res *Response) error {
+		ids := req.Param("id").Values
+		assert.Len(t, ids, 2)
+		return res.WriteString(
+			ids[0].String() + " " + ids[1].String(),
+		)
+	})
+
+	a = New()
+	a.Mount("/posts/:id/comments", child)
+
+	req = httptest.NewRequest(http.MethodGet, "/posts/1/comments/2", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2 1", rec.Body.String())
+
+	child = New()
+	child.GET("/:name", func(req *Request, res *Response) error {
+		assert.Nil(t, req.Param("*"))
+		return res.WriteString(req.Param("name").Value().String())
+	})
+
+	child.GET("/files/*", func(req *Request, res *Response) error {
+		ps := req.Param("*").Values
+		assert.Len(t, ps, 1)
+		return res.WriteString(ps[0].String())
+	})
+
+	a = New()
+	a.Mount("/static", child)
+
+	req = httptest.NewRequest(http.MethodGet, "/static/foo", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "foo", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/static/files/a/b", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "a/b", rec.Body.String())
+}
@@ -1,19 +1,16 @@
 package air
 
 import (
+	"encoding"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"mime"
+	"mime/multipart"
 	"net/http"
 	"reflect"
 	"strings"
-
-	"github.com/BurntSushi/toml"
-	"github.com/golang/protobuf/proto"
-	"github.com/vmihailenco/msgpack"
-	yaml "gopkg.in/yaml.v2"
+	"time"
 )
 
 // binder is a binder that binds request based on the MIME types.
@@ -30,47 +27,86 @@ func newBinder(a *Air) *binder {
 
 // bind binds the r into the v.
 func (b *binder) bind(v interface{}, r *Request) error {
+	if err := b.bindBody(v, r); err != nil {
+		return err
+	}
+
+	return b.bindSources(v, r)
+}
+
+// bindBody binds the body of the r into the v. The params of the r are bound
+// instead if the r is a GET, HEAD or DELETE request without body.
+func (b *binder) bindBody(v interface{}, r *Request) error {
 	if r.ContentLength == 0 {
 		switch r.Method {
 		case http.MethodGet, http.MethodHead, http.MethodDelete:
 			return b.bindParams(v, r.Params())
 		}
 
-		return errors.New("air: request body cannot be empty")
+		return &BindError{
+			Source: "body",
+			Err:    errors.New("air: request body cannot be empty"),
+		}
 	}
 
 	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
-		return err
+		return &BindError{
+			Field:  "Content-Type",
+			Source: "header",
+			Err:    err,
+		}
 	}
 
 	switch mt {
-	case "application/json":
-		err = json.NewDecoder(r.Body).Decode(v)
-	case "application/xml":
-		err = xml.NewDecoder(r.Body).Decode(v)
-	case "application/protobuf":
-		var b []byte
-		if b, err = ioutil.ReadAll(r.Body); err == nil {
-			err = proto.Unmarshal(b, v.(proto.Message))
-		}
-	case "application/msgpack":
-		err = msgpack.NewDecoder(r.Body).Decode(v)
-	case "application/toml":
-		_, err = toml.DecodeReader(r.Body, v)
-	case "application/yaml":
-		err = yaml.NewDecoder(r.Body).Decode(v)
 	case "application/x-www-form-urlencoded", "multipart/form-data":
-		err = b.bindParams(v, r.Params())
-	default:
+		ps := r.Params()
+		if err := r.otherParamsError; err != nil {
+			return &BindError{
+				Source: "body",
+				Err:    err,
+			}
+		}
+
+		return b.bindParams(v, ps)
+	}
+
+	c := b.a.Codec(mt)
+	if c == nil {
 		r.res.Status = http.StatusUnsupportedMediaType
-		err = errors.New(http.StatusText(r.res.Status))
+		return &BindError{
+			Source: "body",
+			Err:    errors.New(http.StatusText(r.res.Status)),
+		}
+	}
+
+	if err := c.Decode(r.Body, v); err != nil {
+		be := &BindError{
+			Source: "body",
+			Err:    err,
+		}
+
+		var ute *json.UnmarshalTypeError
+		if errors.As(err, &ute) {
+			be.Field = ute.Field
+			be.Type = ute.Type.String()
+		}
+
+		return be
 	}
 
-	return err
+	return nil
 }
 
-// bindParams binds the ps into the v.
+// bindParams binds the ps into the v. The fields of the v that have the
+// "query", "header" or "cookie" tags are skipped, since they can only be bound
+// from the corresponding sources (see the `bindSources`).
+//
+// The fields of the v can be of the scalar kinds, the `time.Time`, the
+// `time.Duration`, the types implementing the `encoding.TextUnmarshaler`, the
+// `*multipart.FileHeader`, the pointers to them (for optional fields), the
+// slices of them (bound from repeated params) and the maps from strings to
+// them (bound from the params named with the form of "name[key]").
 func (b *binder) bindParams(v interface{}, ps []*RequestParam) error {
 	t := reflect.TypeOf(v).Elem()
 	if t.Kind() != reflect.Struct {
@@ -85,9 +121,14 @@ func (b *binder) bindParams(v interface{}, ps []*RequestParam) error {
 		}
 
 		tf := t.Field(i)
+		if hasTag(tf, nonParamBindingSources) {
+			continue
+		}
+
 		pn := tf.Tag.Get("param")
 		if pn == "" {
-			if vf.Kind() == reflect.Struct {
+			if vf.Kind() == reflect.Struct &&
+				!bindableValueType(tf.Type) {
 				err := b.bindParams(vf.Addr().Interface(), ps)
 				if err != nil {
 					return err
@@ -101,63 +142,406 @@ func (b *binder) bindParams(v interface{}, ps []*RequestParam) error {
 
 		lpn := strings.ToLower(pn)
 
-		var pv *RequestParamValue
+		if tf.Type.Kind() == reflect.Map {
+			if err := b.bindMap(vf, pn, lpn, ps); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		var pvs []*RequestParamValue
 		for _, p := range ps {
 			if p.Name == pn {
-				pv = p.Value()
+				pvs = p.Values
 				break
-			} else if p.Name == lpn && pv == nil {
-				pv = p.Value()
+			} else if p.Name == lpn && pvs == nil {
+				pvs = p.Values
 			}
 		}
 
-		if pv == nil {
+		if len(pvs) == 0 {
 			continue
 		}
 
-		switch tf.Type.Kind() {
-		case reflect.Bool:
-			b, err := pv.Bool()
-			if err != nil {
-				return err
+		if err := b.bindValues(vf, pvs); err != nil {
+			return newBindError("param", pn, tf.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// bindSources binds the values from the sources of the r into the fields of the
+// v that have the "param", "query", "header" or "cookie" tags. If a field has
+// more than one of them, the first one that has values in the order of
+// "param", "query", "header" and "cookie" wins. The nested structs without any
+// of these tags are bound recursively.
+func (b *binder) bindSources(v interface{}, r *Request) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	val = val.Elem()
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		vf := val.Field(i)
+		if !vf.CanSet() {
+			continue
+		}
+
+		tf := t.Field(i)
+
+		bound := false
+		for _, source := range bindingSources {
+			n := tf.Tag.Get(source)
+			if n == "" || n == "-" {
+				continue
 			}
 
-			vf.SetBool(b)
-		case reflect.Int,
-			reflect.Int8,
-			reflect.Int16,
-			reflect.Int32,
-			reflect.Int64:
-			i64, err := pv.Int64()
-			if err != nil {
-				return err
+			bound = true
+
+			if tf.Type.Kind() == reflect.Map {
+				if source != "param" {
+					continue
+				}
+
+				err := b.bindMap(
+					vf,
+					n,
+					strings.ToLower(n),
+					r.routeParams(),
+				)
+				if err != nil {
+					return err
+				}
+
+				break
 			}
 
-			vf.SetInt(i64)
-		case reflect.Uint,
-			reflect.Uint8,
-			reflect.Uint16,
-			reflect.Uint32,
-			reflect.Uint64:
-			ui64, err := pv.Uint64()
-			if err != nil {
-				return err
+			pvs := sourceValues(r, source, n)
+			if len(pvs) == 0 {
+				continue
+			}
+
+			if err := b.bindValues(vf, pvs); err != nil {
+				return newBindError(source, n, tf.Type, err)
 			}
 
-			vf.SetUint(ui64)
-		case reflect.Float32, reflect.Float64:
-			f64, err := pv.Float64()
+			break
+		}
+
+		if !bound && vf.Kind() == reflect.Struct &&
+			!bindableValueType(tf.Type) {
+			err := b.bindSources(vf.Addr().Interface(), r)
 			if err != nil {
 				return err
 			}
+		}
+	}
 
-			vf.SetFloat(f64)
-		case reflect.String:
-			vf.SetString(pv.String())
-		default:
-			return errors.New("air: unknown binding type")
+	return nil
+}
+
+// sourceValues returns the values named with the n from the source of the r.
+// The "param" source only consists of the route params, so that they never
+// override the body with the values from the request query or form.
+func sourceValues(r *Request, source, n string) []*RequestParamValue {
+	var pvs []*RequestParamValue
+	switch source {
+	case "param":
+		pvs = r.sourcedParamValues(n, requestParamSourceRoute)
+	case "query":
+		pvs = r.sourcedParamValues(n, requestParamSourceQuery)
+	case "header":
+		for _, v := range r.Header[http.CanonicalHeaderKey(n)] {
+			pvs = append(pvs, &RequestParamValue{
+				i: v,
+			})
+		}
+	case "cookie":
+		if c := r.Cookie(n); c != nil {
+			pvs = append(pvs, &RequestParamValue{
+				i: c.Value,
+			})
 		}
 	}
 
+	return pvs
+}
+
+// bindingSources is the tag keys of the binding sources in order of precedence.
+var bindingSources = []string{"param", "query", "header", "cookie"}
+
+// nonParamBindingSources is the tag keys of the binding sources that the params
+// of a request must never be bound into.
+var nonParamBindingSources = bindingSources[1:]
+
+// hasTag reports whether the tf has any of the tags with the keys, ignoring the
+// ones that are "-".
+func hasTag(tf reflect.StructField, keys []string) bool {
+	for _, k := range keys {
+		if n := tf.Tag.Get(k); n != "" && n != "-" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bindMap binds the params in the ps named with the form of "pn[key]" (or
+// "lpn[key]") into the map v.
+func (b *binder) bindMap(
+	v reflect.Value,
+	pn string,
+	lpn string,
+	ps []*RequestParam,
+) error {
+	t := v.Type()
+	if t.Key().Kind() != reflect.String {
+		return errUnknownBindingType
+	}
+
+	for _, p := range ps {
+		if len(p.Values) == 0 || !strings.HasSuffix(p.Name, "]") {
+			continue
+		}
+
+		var k string
+		if strings.HasPrefix(p.Name, pn+"[") {
+			k = p.Name[len(pn)+1 : len(p.Name)-1]
+		} else if strings.HasPrefix(p.Name, lpn+"[") {
+			k = p.Name[len(lpn)+1 : len(p.Name)-1]
+		} else {
+			continue
+		}
+
+		ev := reflect.New(t.Elem()).Elem()
+		if err := b.bindValues(ev, p.Values); err != nil {
+			return newBindError("param", p.Name, t.Elem(), err)
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+
+		v.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), ev)
+	}
+
+	return nil
+}
+
+// bindValues binds the pvs into the v. All of the pvs are bound if the v is a
+// slice, otherwise only the first one is bound.
+func (b *binder) bindValues(v reflect.Value, pvs []*RequestParamValue) error {
+	if v.Kind() != reflect.Slice || bindableValueType(v.Type()) {
+		return b.bindValue(v, pvs[0])
+	}
+
+	sv := reflect.MakeSlice(v.Type(), len(pvs), len(pvs))
+	for i, pv := range pvs {
+		if err := b.bindValue(sv.Index(i), pv); err != nil {
+			return err
+		}
+	}
+
+	v.Set(sv)
+
 	return nil
 }
+
+// bindValue binds the pv into the v.
+func (b *binder) bindValue(v reflect.Value, pv *RequestParamValue) error {
+	t := v.Type()
+	switch {
+	case t == fileHeaderType:
+		f, err := pv.File()
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(f))
+
+		return nil
+	case t == durationType:
+		d, err := time.ParseDuration(pv.String())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	case reflect.PtrTo(t).Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).
+			UnmarshalText([]byte(pv.String()))
+	case t.Kind() == reflect.Ptr:
+		ev := reflect.New(t.Elem())
+		if err := b.bindValue(ev.Elem(), pv); err != nil {
+			return err
+		}
+
+		v.Set(ev)
+
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := pv.Bool()
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		i64, err := pv.Int64()
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i64)
+	case reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		ui64, err := pv.Uint64()
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(ui64)
+	case reflect.Float32, reflect.Float64:
+		f64, err := pv.Float64()
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f64)
+	case reflect.String:
+		v.SetString(pv.String())
+	default:
+		return errUnknownBindingType
+	}
+
+	return nil
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf(&multipart.FileHeader{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf(
+		(*encoding.TextUnmarshaler)(nil),
+	).Elem()
+)
+
+// errUnknownBindingType is the error returned when binding a field of an
+// unknown type.
+var errUnknownBindingType = errors.New("air: unknown binding type")
+
+// bindableValueType reports whether the t is bound as a single value even if it
+// is a struct or a slice (e.g. the `time.Time` and the `net.IP`).
+func bindableValueType(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// BindError is the error that occurs when binding a request fails.
+//
+// The `Request.Bind` sets the status of the response to 400 when it returns a
+// `*BindError`, unless a more specific status has been set (e.g. 413 for too
+// large request bodies and 415 for unsupported media types).
+type BindError struct {
+	// Field is the name of the field that fails to bind, which is the name
+	// of the param, the header or the cookie, or the path of the field in
+	// the request body if it is known. It is empty if the failure is not
+	// about a specific field.
+	Field string
+
+	// Source is the source that the `Field` is bound from. It is one of
+	// the "param", the "query", the "header", the "cookie" and the "body".
+	Source string
+
+	// Type is the expected type of the `Field`. It is empty if unknown.
+	Type string
+
+	// Err is the underlying cause.
+	Err error
+}
+
+// newBindError returns a new instance of the `BindError` for the field named
+// with the name of the t bound from the source with the err. It returns the err
+// as it is if the err is the `errUnknownBindingType`.
+func newBindError(source, name string, t reflect.Type, err error) error {
+	if err == errUnknownBindingType {
+		return err
+	}
+
+	return &BindError{
+		Field:  name,
+		Source: source,
+		Type:   t.String(),
+		Err:    err,
+	}
+}
+
+// Error implements the `error`.
+func (be *BindError) Error() string {
+	if be.Field == "" {
+		return fmt.Sprintf(
+			"air: failed to bind %s: %v",
+			be.Source,
+			be.Err,
+		)
+	} else if be.Type == "" {
+		return fmt.Sprintf(
+			"air: failed to bind %s %q: %v",
+			be.Source,
+			be.Field,
+			be.Err,
+		)
+	}
+
+	return fmt.Sprintf(
+		"air: failed to bind %s %q as %s: %v",
+		be.Source,
+		be.Field,
+		be.Type,
+		be.Err,
+	)
+}
+
+// Unwrap returns the `Err` of the be.
+func (be *BindError) Unwrap() error {
+	return be.Err
+}
+
+// message returns the message of the be that is safe to be shown to the
+// clients. It never contains the `Err` of the be.
+func (be *BindError) message() string {
+	source := be.Source
+	switch source {
+	case "query":
+		source += " param"
+	case "body":
+		source = "request body"
+	}
+
+	if be.Field == "" {
+		return "Invalid " + source
+	} else if be.Type == "" {
+		return fmt.Sprintf("Invalid %s %q", source, be.Field)
+	}
+
+	return fmt.Sprintf(
+		"Invalid %s %q: expected %s",
+		source,
+		be.Field,
+		be.Type,
+	)
+}
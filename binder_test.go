@@ -3,10 +3,13 @@ package air
 import (
 	"bytes"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/stretchr/testify/assert"
@@ -398,3 +401,346 @@ func TestBindFormData(t *testing.T) {
 	assert.Equal(t, "bar", f.Foo)
 	assert.Equal(t, "foo", f.Bar)
 }
+
+func TestBindParamsComplexTypes(t *testing.T) {
+	a := New()
+	b := a.binder
+
+	type foobar struct {
+		Ints     []int             `param:"ints"`
+		Optional *int              `param:"optional"`
+		Missing  *int              `param:"missing"`
+		Time     time.Time         `param:"time"`
+		Duration time.Duration     `param:"duration"`
+		IP       net.IP            `param:"ip"`
+		Labels   map[string]string `param:"labels"`
+		Counts   map[string]int    `param:"counts"`
+		Avatar   *multipart.FileHeader
+		Photos   []*multipart.FileHeader `param:"photos"`
+	}
+
+	buf := bytes.Buffer{}
+	mpw := multipart.NewWriter(&buf)
+	mpw.WriteField("labels[foo]", "bar")
+	mpw.WriteField("counts[foo]", "1")
+	fw, _ := mpw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("avatar"))
+	fw, _ = mpw.CreateFormFile("photos", "1.png")
+	fw.Write([]byte("1"))
+	fw, _ = mpw.CreateFormFile("photos", "2.png")
+	fw.Write([]byte("2"))
+	mpw.Close()
+
+	req, _, _ := fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/foobar"+
+			"?ints=1&ints=2&ints=3"+
+			"&optional=1"+
+			"&time=2006-01-02T15:04:05Z"+
+			"&duration=1m30s"+
+			"&ip=127.0.0.1"+
+			"&labels[bar]=foo",
+		bytes.NewReader(buf.Bytes()),
+	)
+	req.Header.Set("Content-Type", mpw.FormDataContentType())
+
+	f := foobar{}
+	assert.NoError(t, b.bind(&f, req))
+	assert.Equal(t, []int{1, 2, 3}, f.Ints)
+	assert.NotNil(t, f.Optional)
+	assert.Equal(t, 1, *f.Optional)
+	assert.Nil(t, f.Missing)
+	assert.Equal(
+		t,
+		time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		f.Time,
+	)
+	assert.Equal(t, 90*time.Second, f.Duration)
+	assert.Equal(t, "127.0.0.1", f.IP.String())
+	assert.Equal(
+		t,
+		map[string]string{"foo": "bar", "bar": "foo"},
+		f.Labels,
+	)
+	assert.Equal(t, map[string]int{"foo": 1}, f.Counts)
+	assert.NotNil(t, f.Avatar)
+	assert.Equal(t, "avatar.png", f.Avatar.Filename)
+	assert.Len(t, f.Photos, 2)
+	assert.Equal(t, "2.png", f.Photos[1].Filename)
+
+	type invalid struct {
+		Foo map[int]string `param:"foo"`
+	}
+
+	req, _, _ = fakeRRCycle(a, http.MethodGet, "/foobar?foo[1]=bar", nil)
+	assert.Error(t, b.bind(&invalid{}, req))
+}
+
+func TestBindSources(t *testing.T) {
+	a := New()
+	b := a.binder
+
+	type meta struct {
+		RequestID string `header:"X-Request-ID"`
+	}
+
+	type user struct {
+		ID      int      `json:"id" param:"id"`
+		Name    string   `json:"name"`
+		Page    int      `json:"page" query:"page"`
+		Tags    []string `query:"tag" header:"X-Tag"`
+		Session string   `cookie:"session"`
+		Meta    meta
+	}
+
+	req, _, _ := fakeRRCycle(
+		a,
+		http.MethodPut,
+		"/users/1?page=2&tag=foo&tag=bar",
+		strings.NewReader(`{"id":3,"name":"foo","page":4}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "foobar")
+	req.Header.Set("X-Tag", "baz")
+	req.Header.Set("Cookie", "session=barfoo")
+	req.routeParamNames = []string{"id"}
+	req.routeParamValues = []string{"1"}
+
+	u := user{}
+	assert.NoError(t, b.bind(&u, req))
+	assert.Equal(t, 1, u.ID)
+	assert.Equal(t, "foo", u.Name)
+	assert.Equal(t, 2, u.Page)
+	assert.Equal(t, []string{"foo", "bar"}, u.Tags)
+	assert.Equal(t, "barfoo", u.Session)
+	assert.Equal(t, "foobar", u.Meta.RequestID)
+
+	req, _, _ = fakeRRCycle(
+		a,
+		http.MethodPut,
+		"/users/1",
+		strings.NewReader(`{"id":3,"name":"foo","page":4}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tag", "baz")
+
+	u = user{}
+	assert.NoError(t, b.bind(&u, req))
+	assert.Equal(t, 3, u.ID)
+	assert.Equal(t, 4, u.Page)
+	assert.Equal(t, []string{"baz"}, u.Tags)
+	assert.Empty(t, u.Session)
+
+	req, _, _ = fakeRRCycle(
+		a,
+		http.MethodPut,
+		"/users/1?id=5&name=bar",
+		strings.NewReader(`{"id":3,"name":"foo"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+
+	u = user{}
+	assert.NoError(t, b.bind(&u, req))
+	assert.Equal(t, 3, u.ID)
+	assert.Equal(t, "foo", u.Name)
+
+	req, _, _ = fakeRRCycle(
+		a,
+		http.MethodGet,
+		"/users?name=foo&Session=bar&session=bar&RequestID=baz"+
+			"&Tags=qux",
+		nil,
+	)
+
+	u = user{}
+	assert.NoError(t, b.bind(&u, req))
+	assert.Equal(t, "foo", u.Name)
+	assert.Empty(t, u.Session)
+	assert.Empty(t, u.Meta.RequestID)
+	assert.Empty(t, u.Tags)
+
+	req, _, _ = fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/users",
+		strings.NewReader("name=foo&Session=bar&RequestID=baz&Page=2"),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	u = user{}
+	assert.NoError(t, b.bind(&u, req))
+	assert.Equal(t, "foo", u.Name)
+	assert.Empty(t, u.Session)
+	assert.Empty(t, u.Meta.RequestID)
+	assert.Zero(t, u.Page)
+}
+
+func TestBindError(t *testing.T) {
+	a := New()
+
+	type foobar struct {
+		Page int       `param:"page"`
+		Tags []int     `query:"tag"`
+		Time time.Time `header:"X-Time"`
+		Foo  string    `json:"foo"`
+	}
+
+	req, res, _ := fakeRRCycle(a, http.MethodGet, "/?page=foo", nil)
+	err := req.Bind(&foobar{})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.Status)
+
+	be, ok := err.(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, "page", be.Field)
+	assert.Equal(t, "param", be.Source)
+	assert.Equal(t, "int", be.Type)
+	assert.Error(t, be.Unwrap())
+	assert.Contains(
+		t,
+		be.Error(),
+		`air: failed to bind param "page" as int: `,
+	)
+	assert.Equal(t, `Invalid param "page": expected int`, be.message())
+
+	req, _, _ = fakeRRCycle(a, http.MethodGet, "/?tag=1&tag=foo", nil)
+	be, ok = req.Bind(&foobar{}).(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, "tag", be.Field)
+	assert.Equal(t, "query", be.Source)
+	assert.Equal(t, "[]int", be.Type)
+	assert.Equal(
+		t,
+		`Invalid query param "tag": expected []int`,
+		be.message(),
+	)
+
+	type search struct {
+		Page   int `query:"page"`
+		Offset int `query:"from"`
+	}
+
+	for target, field := range map[string]string{
+		"/s?page=x": "page",
+		"/s?from=x": "from",
+	} {
+		req, _, _ = fakeRRCycle(a, http.MethodGet, target, nil)
+		be, ok = req.Bind(&search{}).(*BindError)
+		assert.True(t, ok)
+		assert.Equal(t, "query", be.Source)
+		assert.Equal(t, field, be.Field)
+		assert.Equal(t, "int", be.Type)
+	}
+
+	req, _, _ = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Header.Set("X-Time", "foo")
+	be, ok = req.Bind(&foobar{}).(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, "X-Time", be.Field)
+	assert.Equal(t, "header", be.Source)
+	assert.Equal(t, "time.Time", be.Type)
+
+	req, res, _ = fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"foo":1}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	be, ok = req.Bind(&foobar{}).(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, res.Status)
+	assert.Equal(t, "foo", be.Field)
+	assert.Equal(t, "body", be.Source)
+	assert.Equal(t, "string", be.Type)
+	assert.Equal(
+		t,
+		`Invalid request body "foo": expected string`,
+		be.message(),
+	)
+
+	req, _, _ = fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"foo":`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	be, ok = req.Bind(&foobar{}).(*BindError)
+	assert.True(t, ok)
+	assert.Empty(t, be.Field)
+	assert.Equal(t, "Invalid request body", be.message())
+
+	req, res, _ = fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/",
+		strings.NewReader("foobar"),
+	)
+	req.Header.Set("Content-Type", "text/plain")
+	_, ok = req.Bind(&foobar{}).(*BindError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Status)
+
+	type invalid struct {
+		Foo complex64 `param:"foo"`
+	}
+
+	req, res, _ = fakeRRCycle(a, http.MethodGet, "/?foo=bar", nil)
+	err = req.Bind(&invalid{})
+	assert.Equal(t, errUnknownBindingType, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+}
+
+func TestDefaultErrorHandlerBindError(t *testing.T) {
+	a := New()
+	a.POST("/", func(req *Request, res *Response) error {
+		return req.Bind(&struct {
+			Foo int `json:"foo"`
+		}{})
+	})
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"foo":"bar"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(
+		t,
+		`Invalid request body "foo": expected int`,
+		rec.Body.String(),
+	)
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"foo":"bar"}`),
+	)
+	req.Header.Set("Content-Type", "text/plain")
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, "Unsupported Media Type", rec.Body.String())
+
+	a.DebugMode = true
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"foo":"bar"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(
+		t,
+		rec.Body.String(),
+		`air: failed to bind body "foo" as int: `,
+	)
+}
@@ -0,0 +1,262 @@
+package air
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/golang/protobuf/proto"
+	"github.com/vmihailenco/msgpack"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Codec is a codec that encodes and decodes values in a MIME type.
+//
+// The codecs are registered into the codec registry of an `Air` by calling the
+// `Air.RegisterCodec`, and are used by the `Request.Bind`, the
+// `Response.WriteEncoded` (and the shortcuts such as the `Response.WriteJSON`)
+// and the `Response.WriteNegotiated`.
+type Codec interface {
+	// Encode encodes the v into the w.
+	Encode(w io.Writer, v interface{}) error
+
+	// Decode decodes the r into the v.
+	Decode(r io.Reader, v interface{}) error
+}
+
+// codecRegistry is a registry of the `Codec`s by the MIME types.
+type codecRegistry struct {
+	sync.RWMutex
+
+	a         *Air
+	mimeTypes []string
+	codecs    map[string]Codec
+}
+
+// newCodecRegistry returns a new instance of the `codecRegistry` with the a and
+// the built-in codecs.
+func newCodecRegistry(a *Air) *codecRegistry {
+	cr := &codecRegistry{
+		a:      a,
+		codecs: map[string]Codec{},
+	}
+
+	cr.register("application/json", &jsonCodec{a: a})
+	cr.register("application/xml", &xmlCodec{a: a})
+	cr.register("application/msgpack", &msgpackCodec{})
+	cr.register("application/protobuf", &protobufCodec{})
+	cr.register("application/yaml", &yamlCodec{})
+	cr.register("application/toml", &tomlCodec{})
+
+	return cr
+}
+
+// register registers the c for the mimeType into the cr. It removes the codec
+// for the mimeType if the c is nil. The mimeType is case-insensitive.
+func (cr *codecRegistry) register(mimeType string, c Codec) {
+	mimeType = strings.ToLower(mimeType)
+
+	cr.Lock()
+	defer cr.Unlock()
+
+	if c == nil {
+		if _, ok := cr.codecs[mimeType]; !ok {
+			return
+		}
+
+		delete(cr.codecs, mimeType)
+		for i, mt := range cr.mimeTypes {
+			if mt == mimeType {
+				cr.mimeTypes = append(
+					cr.mimeTypes[:i:i],
+					cr.mimeTypes[i+1:]...,
+				)
+				break
+			}
+		}
+
+		return
+	}
+
+	if _, ok := cr.codecs[mimeType]; !ok {
+		cr.mimeTypes = append(cr.mimeTypes, mimeType)
+	}
+
+	cr.codecs[mimeType] = c
+}
+
+// codec returns the `Codec` for the mimeType in the cr. It returns nil if not
+// found. The mimeType is case-insensitive.
+func (cr *codecRegistry) codec(mimeType string) Codec {
+	mimeType = strings.ToLower(mimeType)
+
+	cr.RLock()
+	defer cr.RUnlock()
+
+	return cr.codecs[mimeType]
+}
+
+// registeredMIMETypes returns the MIME types of the codecs in the cr in order
+// of registration.
+func (cr *codecRegistry) registeredMIMETypes() []string {
+	cr.RLock()
+	defer cr.RUnlock()
+	return append([]string(nil), cr.mimeTypes...)
+}
+
+// jsonCodec is the built-in `Codec` for the "application/json". It encodes with
+// indentation when the `DebugMode` of the a is true.
+type jsonCodec struct {
+	a *Air
+}
+
+// Encode implements the `Codec`.
+func (jc *jsonCodec) Encode(w io.Writer, v interface{}) error {
+	var (
+		b   []byte
+		err error
+	)
+
+	if jc.a.DebugMode {
+		b, err = json.MarshalIndent(v, "", "\t")
+	} else {
+		b, err = json.Marshal(v)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// Decode implements the `Codec`.
+func (jc *jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// xmlCodec is the built-in `Codec` for the "application/xml". It encodes with
+// indentation when the `DebugMode` of the a is true.
+type xmlCodec struct {
+	a *Air
+}
+
+// Encode implements the `Codec`.
+func (xc *xmlCodec) Encode(w io.Writer, v interface{}) error {
+	var (
+		b   []byte
+		err error
+	)
+
+	if xc.a.DebugMode {
+		b, err = xml.MarshalIndent(v, "", "\t")
+	} else {
+		b, err = xml.Marshal(v)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// Decode implements the `Codec`.
+func (xc *xmlCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// protobufCodec is the built-in `Codec` for the "application/protobuf".
+type protobufCodec struct{}
+
+// Encode implements the `Codec`.
+func (protobufCodec) Encode(w io.Writer, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("air: protobuf value must be a proto.Message")
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// Decode implements the `Codec`.
+func (protobufCodec) Decode(r io.Reader, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("air: protobuf value must be a proto.Message")
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return proto.Unmarshal(b, m)
+}
+
+// msgpackCodec is the built-in `Codec` for the "application/msgpack".
+type msgpackCodec struct{}
+
+// Encode implements the `Codec`.
+func (msgpackCodec) Encode(w io.Writer, v interface{}) error {
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// Decode implements the `Codec`.
+func (msgpackCodec) Decode(r io.Reader, v interface{}) error {
+	return msgpack.NewDecoder(r).Decode(v)
+}
+
+// tomlCodec is the built-in `Codec` for the "application/toml".
+type tomlCodec struct{}
+
+// Encode implements the `Codec`.
+func (tomlCodec) Encode(w io.Writer, v interface{}) error {
+	return toml.NewEncoder(w).Encode(v)
+}
+
+// Decode implements the `Codec`.
+func (tomlCodec) Decode(r io.Reader, v interface{}) error {
+	_, err := toml.DecodeReader(r, v)
+	return err
+}
+
+// yamlCodec is the built-in `Codec` for the "application/yaml".
+type yamlCodec struct{}
+
+// Encode implements the `Codec`.
+func (yamlCodec) Encode(w io.Writer, v interface{}) error {
+	return yaml.NewEncoder(w).Encode(v)
+}
+
+// Decode implements the `Codec`.
+func (yamlCodec) Decode(r io.Reader, v interface{}) error {
+	return yaml.NewDecoder(r).Decode(v)
+}
@@ -0,0 +1,149 @@
+package air
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNDJSONCodec struct{}
+
+func (fakeNDJSONCodec) Encode(w io.Writer, v interface{}) error {
+	e := json.NewEncoder(w)
+	for _, i := range v.([]interface{}) {
+		if err := e.Encode(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (fakeNDJSONCodec) Decode(r io.Reader, v interface{}) error {
+	is := v.(*[]interface{})
+	d := json.NewDecoder(r)
+	for d.More() {
+		var i interface{}
+		if err := d.Decode(&i); err != nil {
+			return err
+		}
+
+		*is = append(*is, i)
+	}
+
+	return nil
+}
+
+func TestNewCodecRegistry(t *testing.T) {
+	a := New()
+	cr := a.codecRegistry
+
+	assert.NotNil(t, cr)
+	assert.NotNil(t, cr.a)
+	assert.Equal(
+		t,
+		[]string{
+			"application/json",
+			"application/xml",
+			"application/msgpack",
+			"application/protobuf",
+			"application/yaml",
+			"application/toml",
+		},
+		cr.registeredMIMETypes(),
+	)
+}
+
+func TestAirRegisterCodec(t *testing.T) {
+	a := New()
+
+	assert.Nil(t, a.Codec("application/x-ndjson"))
+
+	a.RegisterCodec("application/x-ndjson", fakeNDJSONCodec{})
+	assert.Equal(t, fakeNDJSONCodec{}, a.Codec("application/x-ndjson"))
+	assert.Equal(
+		t,
+		"application/x-ndjson",
+		a.codecRegistry.registeredMIMETypes()[6],
+	)
+
+	a.RegisterCodec("application/json", fakeNDJSONCodec{})
+	assert.Equal(t, fakeNDJSONCodec{}, a.Codec("application/json"))
+	assert.Equal(
+		t,
+		"application/json",
+		a.codecRegistry.registeredMIMETypes()[0],
+	)
+
+	a.RegisterCodec("application/xml", nil)
+	assert.Nil(t, a.Codec("application/xml"))
+	assert.Len(t, a.codecRegistry.registeredMIMETypes(), 6)
+
+	a.RegisterCodec("application/foobar", nil)
+	assert.Len(t, a.codecRegistry.registeredMIMETypes(), 6)
+
+	a.RegisterCodec("Application/X-CBOR", fakeNDJSONCodec{})
+	assert.Equal(t, fakeNDJSONCodec{}, a.Codec("application/x-cbor"))
+	assert.Equal(t, fakeNDJSONCodec{}, a.Codec("APPLICATION/X-CBOR"))
+	assert.Equal(
+		t,
+		"application/x-cbor",
+		a.codecRegistry.registeredMIMETypes()[6],
+	)
+
+	a.RegisterCodec("APPLICATION/X-CBOR", nil)
+	assert.Nil(t, a.Codec("application/x-cbor"))
+	assert.Len(t, a.codecRegistry.registeredMIMETypes(), 6)
+}
+
+func TestCodecUsages(t *testing.T) {
+	a := New()
+	a.RegisterCodec("application/x-ndjson", fakeNDJSONCodec{})
+
+	req, _, _ := fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/",
+		strings.NewReader("{\"foo\":\"bar\"}\n1\n"),
+	)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	var is []interface{}
+	assert.NoError(t, req.Bind(&is))
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{"foo": "bar"}, 1.0},
+		is,
+	)
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	assert.NoError(t, res.WriteNegotiated(is))
+	assert.Equal(
+		t,
+		"application/x-ndjson",
+		rec.Header().Get("Content-Type"),
+	)
+	assert.Equal(t, "{\"foo\":\"bar\"}\n1\n", rec.Body.String())
+
+	_, res, _ = fakeRRCycle(a, http.MethodGet, "/", nil)
+	assert.Error(t, res.WriteEncoded("application/foobar", is))
+	assert.Error(t, res.WriteEncoded("", is))
+
+	a.RegisterCodec("application/xml", nil)
+
+	req, res, _ = fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/",
+		bytes.NewReader([]byte("<foobar></foobar>")),
+	)
+	req.Header.Set("Content-Type", "application/xml")
+	assert.Error(t, req.Bind(&struct{}{}))
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Status)
+}
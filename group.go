@@ -1,12 +1,40 @@
 package air
 
+import (
+	"net/http"
+)
+
 // Group is a set of sub-routes for a specified route. It can be used for inner
 // routes that share common gases or functionality that should be separate from
 // the parent while still inheriting from it.
+//
+// It is highly recommended not to modify the `Host` and the `Prefix` of the
+// `Group` after it is created, since the requests are matched against the ones
+// that it was created with.
 type Group struct {
 	// Air is where the current group belong.
 	Air *Air
 
+	// Host is the host pattern of all routes of the current group.
+	//
+	// If the `Host` is not empty, all routes registered by the current
+	// group will be put into a separate route tree that is only used for
+	// the requests whose `Request.Authority` (without port) matches it.
+	// The route trees of all matching host patterns are tried in priority
+	// order (exact hosts, then the ones with ":name" labels, then the ones
+	// with a leading "*" label), followed by the one of the routes without
+	// a host.
+	//
+	// The `Host` consists of dot-separated labels. A label of the form
+	// ":name" matches exactly one label of the request host and captures
+	// it as a route param named with the name. A leading "*" label matches
+	// one or more labels of the request host and captures them as a route
+	// param named "*", or named with the name if it is of the form "*name".
+	//
+	// Example: "api.example.com", ":tenant.example.com", "*.example.com",
+	// "*sub.example.com"
+	Host string
+
 	// Prefix is the prefix of all route paths of the current group.
 	//
 	// All paths of routes registered by the current group will share the
@@ -18,69 +46,222 @@ type Group struct {
 	// All gases of routes registered by the current group will share the
 	// same group-level gases at the bottom of the stack.
 	Gases []Gas
+
+	// NotFoundHandler is the `Handler` of the current group that returns
+	// not found error.
+	//
+	// If the `NotFoundHandler` is not nil, it will be used instead of the
+	// `Air.NotFoundHandler` for the requests that fall under the current
+	// group (see the `Host` and the `Prefix`). The one of the group with
+	// the longest matching `Prefix` wins.
+	NotFoundHandler func(*Request, *Response) error
+
+	// MethodNotAllowedHandler is the `Handler` of the current group that
+	// returns method not allowed error.
+	//
+	// If the `MethodNotAllowedHandler` is not nil, it will be used instead
+	// of the `Air.MethodNotAllowedHandler` for the requests that fall under
+	// the current group, just like the `NotFoundHandler`.
+	MethodNotAllowedHandler func(*Request, *Response) error
+
+	// ErrorHandler is the error handler of the current group.
+	//
+	// If the `ErrorHandler` is not nil, it will be used instead of the
+	// `Air.ErrorHandler` for the requests that fall under the current
+	// group, just like the `NotFoundHandler`.
+	ErrorHandler func(error, *Request, *Response)
+
+	// MaxRequestBodyBytes is the maximum number of bytes of the request
+	// bodies of the current group.
+	//
+	// If the `MaxRequestBodyBytes` is not zero, it will be used instead of
+	// the `Air.MaxRequestBodyBytes` for the requests that fall under the
+	// current group, just like the `NotFoundHandler`. A negative value
+	// means no limit.
+	MaxRequestBodyBytes int64
 }
 
 // GET is just like the `Air.GET`.
-func (g *Group) GET(path string, h Handler, gases ...Gas) {
-	g.Air.GET(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) GET(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodGet,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // HEAD is just like the `Air.HEAD`.
-func (g *Group) HEAD(path string, h Handler, gases ...Gas) {
-	g.Air.HEAD(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) HEAD(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodHead,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // POST is just like the `Air.POST`.
-func (g *Group) POST(path string, h Handler, gases ...Gas) {
-	g.Air.POST(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) POST(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodPost,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // PUT is just like the `Air.PUT`.
-func (g *Group) PUT(path string, h Handler, gases ...Gas) {
-	g.Air.PUT(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) PUT(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodPut,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // PATCH is just like the `Air.PATCH`.
-func (g *Group) PATCH(path string, h Handler, gases ...Gas) {
-	g.Air.PATCH(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) PATCH(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodPatch,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // DELETE is just like the `Air.DELETE`.
-func (g *Group) DELETE(path string, h Handler, gases ...Gas) {
-	g.Air.DELETE(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) DELETE(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodDelete,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // CONNECT is just like the `Air.CONNECT`.
-func (g *Group) CONNECT(path string, h Handler, gases ...Gas) {
-	g.Air.CONNECT(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) CONNECT(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodConnect,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // OPTIONS is just like the `Air.OPTIONS`.
-func (g *Group) OPTIONS(path string, h Handler, gases ...Gas) {
-	g.Air.OPTIONS(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) OPTIONS(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodOptions,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // TRACE is just like the `Air.TRACE`.
-func (g *Group) TRACE(path string, h Handler, gases ...Gas) {
-	g.Air.TRACE(g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) TRACE(path string, h Handler, gases ...Gas) *Route {
+	return g.adopt(g.Air.router.registerHost(
+		g.Host,
+		http.MethodTrace,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // BATCH is just like the `Air.BATCH`.
-func (g *Group) BATCH(methods []string, path string, h Handler, gases ...Gas) {
-	g.Air.BATCH(methods, g.Prefix+path, h, append(g.Gases, gases...)...)
+func (g *Group) BATCH(
+	methods []string,
+	path string,
+	h Handler,
+	gases ...Gas,
+) []*Route {
+	return g.adoptRoutes(g.Air.batch(
+		g.Host,
+		methods,
+		g.Prefix+path,
+		h,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // FILE is just like the `Air.FILE`.
-func (g *Group) FILE(path, file string, gases ...Gas) {
-	g.Air.FILE(g.Prefix+path, file, append(g.Gases, gases...)...)
+func (g *Group) FILE(path, file string, gases ...Gas) []*Route {
+	return g.adoptRoutes(g.Air.file(
+		g.Host,
+		g.Prefix+path,
+		file,
+		append(g.Gases, gases...)...,
+	))
 }
 
 // FILES is just like the `Air.FILES`.
-func (g *Group) FILES(prefix, root string, gases ...Gas) {
-	g.Air.FILES(g.Prefix+prefix, root, append(g.Gases, gases...)...)
+func (g *Group) FILES(prefix, root string, gases ...Gas) []*Route {
+	return g.adoptRoutes(g.Air.files(
+		g.Host,
+		g.Prefix+prefix,
+		root,
+		append(g.Gases, gases...)...,
+	))
+}
+
+// Mount is just like the `Air.Mount`.
+func (g *Group) Mount(prefix string, child *Air, gases ...Gas) []*Route {
+	return g.adoptRoutes(g.Air.mount(
+		g.Host,
+		g.Prefix+prefix,
+		child,
+		append(g.Gases, gases...)...,
+	))
+}
+
+// Use appends the gases to the g that will be applied to all requests that fall
+// under the g (see the `Host` and the `Prefix`), including the ones that have
+// no matching routes or methods.
+//
+// The gases run after the `Air.Gases` and before the route-level gases
+// (including the `Gases`). The ones of the group with the shorter `Prefix` run
+// first. The g does not have to be created by the `Air.Group`, a `Group`
+// literal works too.
+func (g *Group) Use(gases ...Gas) {
+	g.Air.router.useGroupGases(g, gases)
 }
 
 // Group is just like the `Air.Group`.
 func (g *Group) Group(prefix string, gases ...Gas) *Group {
-	return g.Air.Group(g.Prefix+prefix, append(g.Gases, gases...)...)
+	sg := &Group{
+		Air:    g.Air,
+		Host:   g.Host,
+		Prefix: g.Prefix + prefix,
+		Gases:  append(g.Gases, gases...),
+	}
+	g.Air.router.registerGroup(sg)
+
+	return sg
+}
+
+// adopt marks the rt as registered by the g.
+func (g *Group) adopt(rt *Route) *Route {
+	rt.GroupPrefix = g.Prefix
+	return rt
+}
+
+// adoptRoutes marks the rs as registered by the g.
+func (g *Group) adoptRoutes(rs []*Route) []*Route {
+	for _, rt := range rs {
+		g.adopt(rt)
+	}
+
+	return rs
 }
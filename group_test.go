@@ -1,6 +1,7 @@
 package air
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -188,3 +189,171 @@ func TestGroup(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	assert.Empty(t, rec.Body.String())
 }
+
+func TestGroupHandlers(t *testing.T) {
+	a := New()
+
+	api := a.Group("/api")
+	api.NotFoundHandler = func(req *Request, res *Response) error {
+		res.Status = http.StatusNotFound
+		return res.WriteJSON(map[string]interface{}{
+			"title": "Not Found",
+		})
+	}
+
+	api.MethodNotAllowedHandler = func(req *Request, res *Response) error {
+		res.Status = http.StatusMethodNotAllowed
+		return res.WriteJSON(map[string]interface{}{
+			"title": "Method Not Allowed",
+		})
+	}
+
+	api.ErrorHandler = func(err error, req *Request, res *Response) {
+		res.WriteJSON(map[string]interface{}{
+			"title": err.Error(),
+		})
+	}
+
+	api.GET("/foo", func(req *Request, res *Response) error {
+		return errors.New("foo")
+	})
+
+	users := api.Group("/users/:id")
+	users.NotFoundHandler = func(req *Request, res *Response) error {
+		res.Status = http.StatusNotFound
+		return res.WriteString("User Not Found")
+	}
+
+	a.GET("/bar", func(req *Request, res *Response) error {
+		return errors.New("bar")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/bar", nil)
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, `{"title":"Not Found"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/foo", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get("Allow"))
+	assert.Equal(t, `{"title":"Method Not Allowed"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/foo", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, `{"title":"foo"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/users/1/bar", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "User Not Found", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/apix", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "Not Found", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/bar", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "Internal Server Error", rec.Body.String())
+
+	v1 := a.Host("*.example.com").Group("/api/v1")
+	v1.NotFoundHandler = func(req *Request, res *Response) error {
+		res.Status = http.StatusNotFound
+		return res.WriteString("V1 Not Found")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/bar", nil)
+	req.Host = "foo.example.com"
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "V1 Not Found", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/bar", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, `{"title":"Not Found"}`, rec.Body.String())
+}
+
+func TestGroupUse(t *testing.T) {
+	a := New()
+
+	trace := func(name string) Gas {
+		return func(next Handler) Handler {
+			return func(req *Request, res *Response) error {
+				res.Header.Add("X-Trace", name)
+				return next(req, res)
+			}
+		}
+	}
+
+	a.Gases = []Gas{trace("air")}
+
+	api := a.Group("/api", trace("api-route"))
+	api.Use(trace("api"))
+	api.GET("/foo", func(req *Request, res *Response) error {
+		return res.WriteString("Matched [GET /api/foo]")
+	})
+
+	v1 := api.Group("/v1")
+	v1.Use(trace("v1"))
+
+	a.GET("/bar", func(req *Request, res *Response) error {
+		return res.WriteString("Matched [GET /bar]")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/foo", nil)
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(
+		t,
+		[]string{"air", "api", "api-route"},
+		rec.Header()["X-Trace"],
+	)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/foo", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, []string{"air", "api"}, rec.Header()["X-Trace"])
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/foo", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, []string{"air", "api", "v1"}, rec.Header()["X-Trace"])
+
+	req = httptest.NewRequest(http.MethodGet, "/bar", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"air"}, rec.Header()["X-Trace"])
+
+	x := &Group{
+		Air:    a,
+		Prefix: "/x",
+	}
+	x.Use(trace("x"))
+	x.Use(trace("x2"))
+
+	req = httptest.NewRequest(http.MethodGet, "/x/foo", nil)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(
+		t,
+		[]string{"air", "x", "x2"},
+		rec.Header()["X-Trace"],
+	)
+}
@@ -77,6 +77,7 @@ func (r *renderer) load() {
 			"substr":  substr,
 			"timefmt": timefmt,
 			"locstr":  locstr,
+			"url":     r.url,
 		}).
 		Funcs(r.a.RendererTemplateFuncMap)
 	if r.loadError = filepath.Walk(
@@ -137,6 +138,17 @@ func (r *renderer) render(
 	}).Execute(w, v)
 }
 
+// url returns a URL path built from the route named with the name by filling its
+// params with the params, which are treated as key-value pairs.
+func (r *renderer) url(name string, params ...interface{}) string {
+	m := make(map[string]interface{}, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		m[fmt.Sprint(params[i])] = params[i+1]
+	}
+
+	return r.a.URL(name, m)
+}
+
 // strlen returns the number of characters in the s.
 func strlen(s string) int {
 	return len([]rune(s))
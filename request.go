@@ -2,11 +2,15 @@ package air
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"strconv"
 	"strings"
@@ -40,6 +44,10 @@ type Request struct {
 	//
 	// For HTTP/2, it is from the ":scheme" pseudo-header.
 	//
+	// If the `RemoteAddress` is in the `Air.TrustedProxies`, it may also be
+	// from the Forwarded header (the "proto" parameter) or the
+	// X-Forwarded-Proto header.
+	//
 	// Example: "http"
 	Scheme string
 
@@ -52,6 +60,10 @@ type Request struct {
 	//
 	// For HTTP/2, it is from the ":authority" pseudo-header.
 	//
+	// If the `RemoteAddress` is in the `Air.TrustedProxies`, it may also be
+	// from the Forwarded header (the "host" parameter) or the
+	// X-Forwarded-Host header.
+	//
 	// Example: "localhost:8080"
 	Authority string
 
@@ -103,11 +115,17 @@ type Request struct {
 	hr                   *http.Request
 	res                  *Response
 	params               []*RequestParam
+	route                *Route
+	routePattern         string
 	routeParamNames      []string
 	routeParamValues     []string
 	parseRouteParamsOnce *sync.Once
 	parseOtherParamsOnce *sync.Once
+	otherParamsError     error
+	queryParams          url.Values
+	multipartReaderUsed  bool
 	localizedString      func(string) string
+	mountMaxBodyBytes    int64
 }
 
 // HTTPRequest returns the underlying `http.Request` of the r.
@@ -173,6 +191,18 @@ func (r *Request) SetHTTPRequest(hr *http.Request) {
 	r.ContentLength = hr.ContentLength
 	r.Context = hr.Context()
 	r.hr = hr
+	r.queryParams = nil
+
+	if fe := r.forwardedClient(); fe != nil {
+		switch p := strings.ToLower(fe.proto); p {
+		case "http", "https":
+			r.Scheme = p
+		}
+
+		if fe.host != "" {
+			r.Authority = fe.host
+		}
+	}
 }
 
 // RemoteAddress returns the last network address that sent the r.
@@ -183,26 +213,123 @@ func (r *Request) RemoteAddress() string {
 // ClientAddress returns the original network address that sent the r.
 //
 // Usually, the original network address is the same as the last network address
-// that sent the r. But, the Forwarded header and the X-Forwarded-For header
-// will be considered, which may affect the return value.
+// that sent the r. But, if the `RemoteAddress` is in the `Air.TrustedProxies`,
+// the Forwarded header and the X-Forwarded-For header will be considered, the
+// first hop from right to left that is not in the `Air.TrustedProxies` will be
+// returned.
 func (r *Request) ClientAddress() string {
-	ca := r.RemoteAddress()
-	if f := r.Header.Get("Forwarded"); f != "" { // See RFC 7239
-		for _, p := range strings.Split(strings.Split(f, ",")[0], ";") {
-			p := strings.TrimSpace(p)
-			if strings.HasPrefix(p, "for=") {
-				ca = strings.TrimSuffix(
-					strings.TrimPrefix(p[4:], "\"["),
-					"]\"",
-				)
-				break
+	if fe := r.forwardedClient(); fe != nil && fe.node != "" {
+		return fe.node
+	}
+
+	return r.RemoteAddress()
+}
+
+// forwardedClient returns the `forwardedElement` that describes the client of
+// the r. It returns nil if the `RemoteAddress` is not in the
+// `Air.TrustedProxies`.
+func (r *Request) forwardedClient() *forwardedElement {
+	if r.Air == nil || !r.Air.trustedProxy(r.RemoteAddress()) {
+		return nil
+	}
+
+	var fes []forwardedElement
+	if fs := r.Header["Forwarded"]; len(fs) > 0 { // See RFC 7239
+		for _, f := range fs {
+			for _, e := range strings.Split(f, ",") {
+				fes = append(fes, parseForwardedElement(e))
 			}
 		}
-	} else if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		ca = strings.TrimSpace(strings.Split(xff, ",")[0])
+	} else {
+		for _, xff := range r.Header["X-Forwarded-For"] {
+			for _, f := range strings.Split(xff, ",") {
+				fes = append(fes, forwardedElement{
+					node: strings.TrimSpace(f),
+				})
+			}
+		}
+	}
+
+	fe, n := &forwardedElement{}, 0 // Hops from right to left
+	for i := len(fes) - 1; i >= 0; i-- {
+		fe, n = &fes[i], len(fes)-1-i
+		if !r.Air.trustedProxy(fe.node) {
+			break
+		}
+	}
+
+	if _, ok := r.Header["Forwarded"]; !ok {
+		xfp := r.Header["X-Forwarded-Proto"]
+		xfh := r.Header["X-Forwarded-Host"]
+		fe.proto = forwardedHeaderValue(xfp, n)
+		fe.host = forwardedHeaderValue(xfh, n)
+	}
+
+	return fe
+}
+
+// forwardedElement is an element of the Forwarded header.
+//
+// See RFC 7239, section 4.
+type forwardedElement struct {
+	node  string
+	proto string
+	host  string
+}
+
+// parseForwardedElement parses the e into a `forwardedElement`.
+func parseForwardedElement(e string) forwardedElement {
+	fe := forwardedElement{}
+	for _, p := range strings.Split(e, ";") {
+		i := strings.IndexByte(p, '=')
+		if i < 0 {
+			continue
+		}
+
+		v := strings.Trim(strings.TrimSpace(p[i+1:]), "\"")
+		switch strings.ToLower(strings.TrimSpace(p[:i])) {
+		case "for":
+			fe.node = forwardedNode(v)
+		case "proto":
+			fe.proto = v
+		case "host":
+			fe.host = v
+		}
 	}
 
-	return ca
+	return fe
+}
+
+// forwardedNode returns the node of the "for" parameter value v of the
+// Forwarded header without port and brackets.
+func forwardedNode(v string) string {
+	if strings.HasPrefix(v, "[") {
+		if i := strings.IndexByte(v, ']'); i > 0 {
+			return v[1:i]
+		}
+	} else if h, _, err := net.SplitHostPort(v); err == nil {
+		return h
+	}
+
+	return v
+}
+
+// forwardedHeaderValue returns the value of the n-th hop from right to left in
+// the comma-separated header values vs (e.g. the ones of the X-Forwarded-Proto
+// header). The values are aligned with the ones of the X-Forwarded-For header
+// from the right, since they are appended by the same proxies. It returns ""
+// if not found.
+func forwardedHeaderValue(vs []string, n int) string {
+	var fvs []string
+	for _, v := range vs {
+		fvs = append(fvs, strings.Split(v, ",")...)
+	}
+
+	if i := len(fvs) - 1 - n; i >= 0 {
+		return strings.TrimSpace(fvs[i])
+	}
+
+	return ""
 }
 
 // Cookie returns the matched `http.Cookie` for the name. It returns nil if not
@@ -219,6 +346,11 @@ func (r *Request) Cookies() []*http.Cookie {
 
 // Param returns the matched `RequestParam` for the name. It returns nil if not
 // found.
+//
+// The values of the `RequestParam` are ordered by their sources: route param
+// values first, then request form values (including request multipart form
+// values and files) and request query values last. Use the `RouteParam`, the
+// `FormParam` or the `QueryParam` when the source of a value matters.
 func (r *Request) Param(name string) *RequestParam {
 	if r.routeParamNames != nil {
 		r.parseRouteParamsOnce.Do(r.parseRouteParams)
@@ -246,6 +378,124 @@ func (r *Request) Params() []*RequestParam {
 	return r.params
 }
 
+// Route returns the matched `Route` of the r. It returns nil if there is no
+// matching route (e.g. the r will be handled by the `Air.NotFoundHandler` or
+// the `Air.MethodNotAllowedHandler`).
+//
+// The `Route` is available once the router has routed the r, which means it is
+// always available in the `Air.Gases` and the route-level gases.
+func (r *Request) Route() *Route {
+	return r.route
+}
+
+// RoutePattern returns the path pattern of the matched route of the r (e.g.
+// "/users/:id"). It returns "" if there is no matching route path.
+//
+// Unlike the `Route`, the `RoutePattern` is also available when the r matches
+// a route path but not any of its methods. So it is a good choice for the label
+// of metrics and logs with a low cardinality.
+func (r *Request) RoutePattern() string {
+	return r.routePattern
+}
+
+// RouteParam returns the first value of the route param for the name. It
+// returns nil if not found.
+//
+// Unlike the `Param`, the `RouteParam` never parses the request body.
+func (r *Request) RouteParam(name string) *RequestParamValue {
+	return r.sourcedParam(name, requestParamSourceRoute)
+}
+
+// QueryParam returns the first value of the request query param for the name.
+// It returns nil if not found.
+//
+// Unlike the `Param`, the `QueryParam` never parses the request body.
+func (r *Request) QueryParam(name string) *RequestParamValue {
+	return r.sourcedParam(name, requestParamSourceQuery)
+}
+
+// FormParam returns the first value of the request form param (including the
+// request multipart form param) for the name. It returns nil if not found.
+//
+// Unlike the `Param`, the `FormParam` never returns values that come from the
+// route params or the request query.
+func (r *Request) FormParam(name string) *RequestParamValue {
+	return r.sourcedParam(name, requestParamSourceForm)
+}
+
+// HeaderParam returns the first value of the header for the name as a
+// `RequestParamValue`. It returns nil if not found.
+func (r *Request) HeaderParam(name string) *RequestParamValue {
+	vs := r.Header[textproto.CanonicalMIMEHeaderKey(name)]
+	if len(vs) == 0 {
+		return nil
+	}
+
+	return &RequestParamValue{
+		i: vs[0],
+	}
+}
+
+// sourcedParam returns the first value of the param for the name that comes
+// from the source. It returns nil if not found.
+func (r *Request) sourcedParam(
+	name string,
+	source requestParamSource,
+) *RequestParamValue {
+	if pvs := r.sourcedParamValues(name, source); len(pvs) > 0 {
+		return pvs[0]
+	}
+
+	return nil
+}
+
+// sourcedParamValues returns all values of the param for the name that come
+// from the source. Only the request form source requires the request body to
+// be parsed.
+func (r *Request) sourcedParamValues(
+	name string,
+	source requestParamSource,
+) []*RequestParamValue {
+	var pvs []*RequestParamValue
+	switch source {
+	case requestParamSourceRoute:
+		if r.routeParamNames != nil {
+			r.parseRouteParamsOnce.Do(r.parseRouteParams)
+		}
+	case requestParamSourceQuery:
+		if r.queryParams == nil {
+			r.queryParams, _ = url.ParseQuery(r.hr.URL.RawQuery)
+		}
+
+		for _, v := range r.queryParams[name] {
+			pvs = append(pvs, &RequestParamValue{
+				i:      v,
+				source: source,
+			})
+		}
+
+		return pvs
+	default:
+		r.Param(name)
+	}
+
+	for _, p := range r.params {
+		if p.Name != name {
+			continue
+		}
+
+		for _, v := range p.Values {
+			if v.source == source {
+				pvs = append(pvs, v)
+			}
+		}
+
+		break
+	}
+
+	return pvs
+}
+
 // parseRouteParams parses the route params sent with the r into the `r.params`.
 func (r *Request) parseRouteParams() {
 	r.growParams(len(r.routeParamNames))
@@ -264,7 +514,8 @@ RouteParamLoop:
 
 			pvs := make([]*RequestParamValue, len(p.Values)+1)
 			pvs[0] = &RequestParamValue{
-				i: pv,
+				i:      pv,
+				source: requestParamSourceRoute,
 			}
 
 			copy(pvs[1:], p.Values)
@@ -277,7 +528,8 @@ RouteParamLoop:
 			Name: pn,
 			Values: []*RequestParamValue{
 				{
-					i: pv,
+					i:      pv,
+					source: requestParamSourceRoute,
 				},
 			},
 		})
@@ -288,95 +540,226 @@ RouteParamLoop:
 	r.routeParamValues = nil
 }
 
-// parseOtherParams parses the other params sent with the r into the `r.params`.
-func (r *Request) parseOtherParams() {
-	if r.hr.Form == nil || r.hr.MultipartForm == nil {
-		r.hr.ParseMultipartForm(32 << 20)
+// routeParams returns a copy of the `r.params` that only contains the values
+// that come from the route params.
+func (r *Request) routeParams() []*RequestParam {
+	if r.routeParamNames != nil {
+		r.parseRouteParamsOnce.Do(r.parseRouteParams)
 	}
 
-	r.growParams(len(r.hr.Form))
-
-FormLoop:
-	for n, vs := range r.hr.Form {
-		if len(vs) == 0 {
-			continue
-		}
-
-		pvs := make([]*RequestParamValue, len(vs))
-		for i, v := range vs {
-			pvs[i] = &RequestParamValue{
-				i: v,
+	var ps []*RequestParam
+	for _, p := range r.params {
+		var pvs []*RequestParamValue
+		for _, v := range p.Values {
+			if v.source == requestParamSourceRoute {
+				pvs = append(pvs, v)
 			}
 		}
 
-		for _, p := range r.params {
-			if p.Name == n {
-				p.Values = append(p.Values, pvs...)
-				continue FormLoop
-			}
+		if len(pvs) > 0 {
+			ps = append(ps, &RequestParam{
+				Name:   p.Name,
+				Values: pvs,
+			})
 		}
-
-		r.params = append(r.params, &RequestParam{
-			Name:   n,
-			Values: pvs,
-		})
 	}
 
+	return ps
+}
+
+// parseOtherParams parses the other params sent with the r into the `r.params`.
+func (r *Request) parseOtherParams() {
+	r.otherParamsError = r.parseForm()
+
 	if mf := r.hr.MultipartForm; mf != nil {
 		r.growParams(len(mf.Value) + len(mf.File))
-
-	MultipartFormValueLoop:
 		for n, vs := range mf.Value {
-			if len(vs) == 0 {
-				continue
+			for _, v := range vs {
+				r.addParamValue(n, v, requestParamSourceForm)
 			}
+		}
 
-			pvs := make([]*RequestParamValue, len(vs))
-			for i, v := range vs {
-				pvs[i] = &RequestParamValue{
-					i: v,
-				}
+		for n, vs := range mf.File {
+			for _, v := range vs {
+				r.addParamValue(n, v, requestParamSourceForm)
 			}
-
-			for _, p := range r.params {
-				if p.Name == n {
-					p.Values = append(p.Values, pvs...)
-					continue MultipartFormValueLoop
-				}
+		}
+	} else {
+		r.growParams(len(r.hr.PostForm))
+		for n, vs := range r.hr.PostForm {
+			for _, v := range vs {
+				r.addParamValue(n, v, requestParamSourceForm)
 			}
+		}
+	}
 
-			r.params = append(r.params, &RequestParam{
-				Name:   n,
-				Values: pvs,
-			})
+	q, _ := url.ParseQuery(r.hr.URL.RawQuery)
+	r.growParams(len(q))
+	for n, vs := range q {
+		for _, v := range vs {
+			r.addParamValue(n, v, requestParamSourceQuery)
 		}
+	}
+}
 
-	MultipartFormFileLoop:
-		for n, vs := range mf.File {
-			if len(vs) == 0 {
-				continue
+// MultipartReader returns a `RequestMultipartReader` that streams the parts of
+// the request multipart form of the r. It returns `http.ErrNotMultipart` if the
+// r is not a multipart/form-data request.
+//
+// The `MultipartReader` gives the handlers the ability to process large uploads
+// (e.g. to pipe them straight to somewhere else) without buffering them into
+// memory or temporary files. It must be called before anything that parses the
+// request multipart form of the r (e.g. the `Param`, the `Params` or the
+// `Bind`). After calling it, the values of the request multipart form will
+// never appear in the `Params`.
+func (r *Request) MultipartReader() (*RequestMultipartReader, error) {
+	if r.multipartReaderUsed {
+		return nil, errors.New("air: multipart reader called twice")
+	} else if r.hr.MultipartForm != nil {
+		return nil, errors.New(
+			"air: request multipart form has been parsed",
+		)
+	}
+
+	mr, err := r.multipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	r.multipartReaderUsed = true
+
+	return &RequestMultipartReader{
+		r:  r,
+		mr: mr,
+	}, nil
+}
+
+// multipartReader returns a `multipart.Reader` that reads the body of the r. It
+// returns `http.ErrNotMultipart` if the r is not a multipart/form-data request.
+func (r *Request) multipartReader() (*multipart.Reader, error) {
+	mt, ps, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt != "multipart/form-data" {
+		return nil, http.ErrNotMultipart
+	}
+
+	boundary := ps["boundary"]
+	if boundary == "" {
+		return nil, http.ErrMissingBoundary
+	}
+
+	return multipart.NewReader(r.hr.Body, boundary), nil
+}
+
+// parseForm parses the request form (including the request multipart form) of
+// the r into the `r.hr.Form`, the `r.hr.PostForm` and the
+// `r.hr.MultipartForm`.
+func (r *Request) parseForm() error {
+	if r.hr.MultipartForm != nil {
+		return nil
+	}
+
+	if err := r.hr.ParseForm(); err != nil {
+		return err
+	} else if r.multipartReaderUsed {
+		return nil
+	}
+
+	mr, err := r.multipartReader()
+	if err == http.ErrNotMultipart {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	mf, err := r.readMultipartForm(&RequestMultipartReader{
+		r:  r,
+		mr: mr,
+	})
+	if err != nil {
+		return err
+	}
+
+	for n, vs := range mf.Value {
+		r.hr.Form[n] = append(r.hr.Form[n], vs...)
+		r.hr.PostForm[n] = append(r.hr.PostForm[n], vs...)
+	}
+
+	r.hr.MultipartForm = mf
+
+	return nil
+}
+
+// readMultipartForm reads the request multipart form of the r from the rmr with
+// respect to the `Air.MultipartFormMaxMemoryBytes`, the
+// `Air.MultipartFormMaxFiles` and the `Air.MultipartFormMaxFields`.
+func (r *Request) readMultipartForm(
+	rmr *RequestMultipartReader,
+) (*multipart.Form, error) {
+	if r.Air.MultipartFormMaxFiles <= 0 &&
+		r.Air.MultipartFormMaxFields <= 0 {
+		return rmr.mr.ReadForm(r.Air.MultipartFormMaxMemoryBytes)
+	}
+
+	// The `multipart.Reader.ReadForm` cannot limit the number of parts, so
+	// the parts are counted by the rmr and piped to another reader that
+	// reads the form.
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		for {
+			p, err := rmr.NextPart()
+			if err == io.EOF {
+				pw.CloseWithError(mw.Close())
+				return
+			} else if err != nil {
+				pw.CloseWithError(err)
+				return
 			}
 
-			pvs := make([]*RequestParamValue, len(vs))
-			for i, v := range vs {
-				pvs[i] = &RequestParamValue{
-					i: v,
-				}
+			w, err := mw.CreatePart(textproto.MIMEHeader(p.Header))
+			if err == nil {
+				_, err = io.Copy(w, p)
 			}
 
-			for _, p := range r.params {
-				if p.Name == n {
-					p.Values = append(p.Values, pvs...)
-					continue MultipartFormFileLoop
-				}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
 			}
+		}
+	}()
 
-			r.params = append(r.params, &RequestParam{
-				Name:   n,
-				Values: pvs,
-			})
+	mf, err := multipart.NewReader(pr, mw.Boundary()).ReadForm(
+		r.Air.MultipartFormMaxMemoryBytes,
+	)
+	pr.Close()
+
+	return mf, err
+}
+
+// addParamValue adds the v that comes from the source into the values of the
+// param named with the n in the `r.params`.
+func (r *Request) addParamValue(
+	n string,
+	v interface{},
+	source requestParamSource,
+) {
+	pv := &RequestParamValue{
+		i:      v,
+		source: source,
+	}
+
+	for _, p := range r.params {
+		if p.Name == n {
+			p.Values = append(p.Values, pv)
+			return
 		}
 	}
+
+	r.params = append(r.params, &RequestParam{
+		Name:   n,
+		Values: []*RequestParamValue{pv},
+	})
 }
 
 // growParams grows the capacity of the `r.params`, if necessary, to guarantee
@@ -402,8 +785,73 @@ func (r *Request) growParams(n int) {
 //   * application/yaml
 //   * application/x-www-form-urlencoded
 //   * multipart/form-data
+//   * The MIME types of the codecs registered by the `Air.RegisterCodec`
+//
+// After the body is bound, the fields of the v that have the "param", "query",
+// "header" or "cookie" tags are bound from the corresponding sources (e.g.
+// `param:"id" query:"page" header:"X-Request-ID" cookie:"session"`), which
+// take precedence over the body. The "param" tags are bound from the route
+// params only in this step, although they also name the fields bound from the
+// form bodies (and from the params of the GET, HEAD and DELETE requests without
+// body). The fields that have the "query", "header" or "cookie" tags are never
+// bound from the body. If a field has more than one of them, the first one that
+// has values in the order of "param", "query", "header" and "cookie" wins.
+//
+// If the r fails to bind, a `*BindError` is returned and the status of the
+// response is set to 400, unless a more specific status has been set (e.g. 413
+// and 415).
+//
+// After binding, the v is validated based on the validate tags of its fields
+// (e.g. `validate:"required,min=1,max=64,email,oneof=a b"`). If any of the
+// fields fails to validate, a `*ValidationError` is returned and the status of
+// the response is set to 422.
+//
+// Supported validation rules:
+//   * required
+//   * omitempty
+//   * min=n
+//   * max=n
+//   * len=n
+//   * email
+//   * oneof=a b c
 func (r *Request) Bind(v interface{}) error {
-	return r.Air.binder.bind(v, r)
+	if err := r.Air.binder.bind(v, r); err != nil {
+		if _, ok := err.(*BindError); ok &&
+			r.res.Status < http.StatusBadRequest {
+			r.res.Status = http.StatusBadRequest
+		}
+
+		return err
+	}
+
+	err := r.Air.validator.validate(v)
+	if _, ok := err.(*ValidationError); ok {
+		r.res.Status = http.StatusUnprocessableEntity
+	}
+
+	return err
+}
+
+// Negotiate returns the most acceptable MIME type among the offers based on
+// the Accept header. It returns "" if none of the offers is acceptable.
+//
+// The q-values and the wildcards (e.g. "*/*" and "text/*") of the Accept header
+// are honored. Among the offers with the same q-value, the former one wins. If
+// the Accept header is absent, the first offer is returned.
+func (r *Request) Negotiate(offers ...string) string {
+	return negotiate(r.Header["Accept"], offers, negotiateMIMEType)
+}
+
+// NegotiateCharset is just like the `Negotiate`, but for the charsets based on
+// the Accept-Charset header.
+func (r *Request) NegotiateCharset(offers ...string) string {
+	return negotiate(r.Header["Accept-Charset"], offers, negotiateToken)
+}
+
+// NegotiateEncoding is just like the `Negotiate`, but for the content codings
+// based on the Accept-Encoding header.
+func (r *Request) NegotiateEncoding(offers ...string) string {
+	return negotiate(r.Header["Accept-Encoding"], offers, negotiateToken)
 }
 
 // LocalizedString returns localized string for the key based on the
@@ -452,15 +900,27 @@ func (rp *RequestParam) Value() *RequestParamValue {
 // It may represent a route param value, a request query value, a request form
 // value, a request multipart form value or a request multipart form file value.
 type RequestParamValue struct {
-	i    interface{}
-	b    *bool
-	i64  *int64
-	ui64 *uint64
-	f64  *float64
-	s    *string
-	f    *multipart.FileHeader
+	i      interface{}
+	source requestParamSource
+	b      *bool
+	i64    *int64
+	ui64   *uint64
+	f64    *float64
+	s      *string
+	f      *multipart.FileHeader
 }
 
+// requestParamSource is the source of a `RequestParamValue`.
+type requestParamSource uint8
+
+// The request param sources.
+const (
+	requestParamSourceOther requestParamSource = iota
+	requestParamSourceRoute
+	requestParamSourceQuery
+	requestParamSourceForm
+)
+
 // Bool returns a `bool` from the underlying value of the rpv.
 func (rpv *RequestParamValue) Bool() (bool, error) {
 	if rpv.b == nil {
@@ -672,16 +1132,211 @@ func (rpv *RequestParamValue) File() (*multipart.FileHeader, error) {
 	return rpv.f, nil
 }
 
+// RequestMultipartReader is a reader that streams the parts of a request
+// multipart form.
+type RequestMultipartReader struct {
+	r      *Request
+	mr     *multipart.Reader
+	files  int
+	fields int
+}
+
+// NextPart returns the next part of the rmr. It returns `io.EOF` if there are
+// no more parts. The rest of the previous part is discarded automatically.
+//
+// The parts without a form name are skipped. The `Air.MultipartFormMaxFiles`
+// and the `Air.MultipartFormMaxFields` are respected, an error is returned and
+// the status of the response is set to 413 once any of them is exceeded.
+func (rmr *RequestMultipartReader) NextPart() (*RequestMultipartPart, error) {
+	for {
+		p, err := rmr.mr.NextPart()
+		if err != nil {
+			return nil, err
+		} else if p.FormName() == "" {
+			continue
+		}
+
+		a := rmr.r.Air
+		if p.FileName() != "" {
+			rmr.files++
+			if a.MultipartFormMaxFiles > 0 &&
+				rmr.files > a.MultipartFormMaxFiles {
+				return nil, rmr.tooLargeError()
+			}
+		} else {
+			rmr.fields++
+			if a.MultipartFormMaxFields > 0 &&
+				rmr.fields > a.MultipartFormMaxFields {
+				return nil, rmr.tooLargeError()
+			}
+		}
+
+		return &RequestMultipartPart{
+			Name:        p.FormName(),
+			Filename:    p.FileName(),
+			ContentType: p.Header.Get("Content-Type"),
+			Header:      http.Header(p.Header),
+
+			p: p,
+		}, nil
+	}
+}
+
+// tooLargeError returns the error of the rmr for the too large request
+// multipart form. It also sets the status of the response to 413 if the
+// response has not been written.
+func (rmr *RequestMultipartReader) tooLargeError() error {
+	if res := rmr.r.res; res != nil && !res.Written {
+		res.Status = http.StatusRequestEntityTooLarge
+	}
+
+	return errors.New(http.StatusText(http.StatusRequestEntityTooLarge))
+}
+
+// RequestMultipartPart is a part of a request multipart form.
+type RequestMultipartPart struct {
+	// Name is the form name of the current part.
+	Name string
+
+	// Filename is the file name of the current part. It is empty if the
+	// current part is not a file.
+	Filename string
+
+	// ContentType is the Content-Type header of the current part.
+	ContentType string
+
+	// Header is the header of the current part.
+	Header http.Header
+
+	p *multipart.Part
+}
+
+// IsFile reports whether the rmp is a file.
+func (rmp *RequestMultipartPart) IsFile() bool {
+	return rmp.Filename != ""
+}
+
+// Read implements the `io.Reader`.
+func (rmp *RequestMultipartPart) Read(b []byte) (int, error) {
+	return rmp.p.Read(b)
+}
+
+// negotiate returns the most acceptable offer among the offers based on the
+// values of an Accept-like header. The match returns the specificity of the
+// spec for the offer, or zero if the spec does not match the offer.
+func negotiate(
+	values []string,
+	offers []string,
+	match func(spec, offer string) int,
+) string {
+	if len(values) == 0 {
+		if len(offers) > 0 {
+			return offers[0]
+		}
+
+		return ""
+	}
+
+	type acceptSpec struct {
+		value string
+		q     float64
+	}
+
+	var specs []acceptSpec
+	for _, s := range strings.Split(strings.Join(values, ","), ",") {
+		ps := strings.Split(s, ";")
+		spec := acceptSpec{
+			value: strings.ToLower(strings.TrimSpace(ps[0])),
+			q:     1,
+		}
+
+		if spec.value == "" {
+			continue
+		}
+
+		for _, p := range ps[1:] {
+			p = strings.ToLower(strings.TrimSpace(p))
+			if !strings.HasPrefix(p, "q=") {
+				continue
+			}
+
+			if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+				spec.q = q
+			}
+
+			break
+		}
+
+		specs = append(specs, spec)
+	}
+
+	bestOffer, bestQ := "", 0.0
+	for _, offer := range offers {
+		o := strings.ToLower(offer)
+		if i := strings.IndexByte(o, ';'); i >= 0 {
+			o = strings.TrimSpace(o[:i])
+		}
+
+		q, specificity := 0.0, 0
+		for _, spec := range specs {
+			if s := match(spec.value, o); s > specificity {
+				q, specificity = spec.q, s
+			}
+		}
+
+		if q > bestQ {
+			bestOffer, bestQ = offer, q
+		}
+	}
+
+	return bestOffer
+}
+
+// negotiateMIMEType returns the specificity of the spec for the offer, where
+// both of them are MIME types.
+func negotiateMIMEType(spec, offer string) int {
+	if spec == offer {
+		return 3
+	} else if spec == "*/*" {
+		return 1
+	}
+
+	if i := strings.IndexByte(offer, '/'); i > 0 &&
+		strings.HasSuffix(spec, "/*") &&
+		spec[:len(spec)-2] == offer[:i] {
+		return 2
+	}
+
+	return 0
+}
+
+// negotiateToken returns the specificity of the spec for the offer, where both
+// of them are tokens (e.g. charsets and content codings).
+func negotiateToken(spec, offer string) int {
+	if spec == offer {
+		return 2
+	} else if spec == "*" {
+		return 1
+	}
+
+	return 0
+}
+
 // requestBody used to tie the `Request.Body` and the `http.Request.Body`
 // together.
 type requestBody struct {
 	sync.Mutex
 
-	r      *Request
-	hr     *http.Request
-	rc     io.ReadCloser
-	cl     int64
-	sawEOF bool
+	r        *Request
+	hr       *http.Request
+	rc       io.ReadCloser
+	cl       int64
+	mcl      int64
+	mclAir   *Air
+	mclRoute *Route
+	mclPath  string
+	sawEOF   bool
+	tooLarge bool
 }
 
 // Read implements the `io.Reader`.
@@ -694,6 +1349,35 @@ func (rb *requestBody) Read(b []byte) (n int, err error) {
 		return
 	}
 
+	// The maximum number of bytes may change after the r is routed (or
+	// handed over to a mounted `Air`), so it is resolved again whenever
+	// that happens.
+
+	if rb.mclAir != rb.r.Air ||
+		rb.mclRoute != rb.r.route ||
+		rb.mclPath != rb.r.Path {
+		rb.mcl = rb.r.Air.maxRequestBodyBytes(rb.r)
+		rb.mclAir = rb.r.Air
+		rb.mclRoute = rb.r.route
+		rb.mclPath = rb.r.Path
+	}
+
+	if rb.mcl > 0 {
+		if rb.tooLarge ||
+			rb.r.ContentLength > rb.mcl ||
+			rb.cl > rb.mcl {
+			err = rb.tooLargeError()
+			return
+		}
+
+		// Read at most one byte more than allowed to find out whether
+		// the body is too large.
+
+		if rl := rb.mcl - rb.cl + 1; int64(len(b)) > rl {
+			b = b[:rl]
+		}
+	}
+
 	if rb.r.ContentLength < 0 {
 		n, err = rb.rc.Read(b)
 	} else if rl := rb.r.ContentLength - rb.cl; rl > 0 {
@@ -705,6 +1389,13 @@ func (rb *requestBody) Read(b []byte) (n int, err error) {
 	}
 
 	rb.cl += int64(n)
+	if rb.mcl > 0 && rb.cl > rb.mcl {
+		n -= int(rb.cl - rb.mcl)
+		rb.cl = rb.mcl
+		err = rb.tooLargeError()
+		return
+	}
+
 	if err == nil && rb.r.ContentLength >= 0 &&
 		rb.r.ContentLength-rb.cl <= 0 {
 		if err = rb.rc.Close(); err != nil {
@@ -730,6 +1421,18 @@ func (rb *requestBody) Read(b []byte) (n int, err error) {
 	return
 }
 
+// tooLargeError marks the rb as too large and returns the corresponding error.
+// It also sets the status of the response to 413 if the response has not been
+// written.
+func (rb *requestBody) tooLargeError() error {
+	rb.tooLarge = true
+	if res := rb.r.res; res != nil && !res.Written {
+		res.Status = http.StatusRequestEntityTooLarge
+	}
+
+	return errors.New(http.StatusText(http.StatusRequestEntityTooLarge))
+}
+
 // Close implements the `io.Closer`.
 func (rb *requestBody) Close() error {
 	return nil
@@ -0,0 +1,501 @@
+package air
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestSourcedParams(t *testing.T) {
+	a := New()
+
+	req, _, _ := fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/users/1?role=admin&page=2",
+		strings.NewReader("role=user&active=true"),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Limit", "10")
+	req.routeParamNames = []string{"id"}
+	req.routeParamValues = []string{"1"}
+
+	assert.Equal(t, "user", req.Param("role").Value().String())
+	assert.Len(t, req.Param("role").Values, 2)
+
+	assert.Equal(t, "user", req.FormParam("role").String())
+	assert.Equal(t, "admin", req.QueryParam("role").String())
+	assert.Nil(t, req.RouteParam("role"))
+
+	i, err := req.RouteParam("id").Int()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i)
+	assert.Nil(t, req.QueryParam("id"))
+	assert.Nil(t, req.FormParam("id"))
+
+	i, err = req.QueryParam("page").Int()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, i)
+	assert.Nil(t, req.FormParam("page"))
+
+	b, err := req.FormParam("active").Bool()
+	assert.NoError(t, err)
+	assert.True(t, b)
+	assert.Nil(t, req.QueryParam("active"))
+
+	i, err = req.HeaderParam("x-limit").Int()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, i)
+	assert.Nil(t, req.HeaderParam("X-Foobar"))
+
+	assert.Nil(t, req.QueryParam("foobar"))
+}
+
+func TestRequestMultipartFormParams(t *testing.T) {
+	a := New()
+
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	mw.WriteField("role", "user")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("foobar"))
+	mw.Close()
+
+	req, _, _ := fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/?role=admin",
+		buf,
+	)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	assert.Len(t, req.Param("role").Values, 2)
+	assert.Equal(t, "user", req.FormParam("role").String())
+	assert.Equal(t, "admin", req.QueryParam("role").String())
+
+	fh, err := req.FormParam("avatar").File()
+	assert.NoError(t, err)
+	assert.Equal(t, "avatar.png", fh.Filename)
+}
+
+func TestRequestBodyMaxBytes(t *testing.T) {
+	a := New()
+	a.MaxRequestBodyBytes = 4
+
+	h := func(req *Request, res *Response) error {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+
+		return res.WriteString(string(b))
+	}
+
+	a.POST("/", h)
+	a.POST("/large", h).MaxRequestBodyBytes = 8
+
+	g := a.Group("/unlimited")
+	g.MaxRequestBodyBytes = -1
+	g.POST("/", h)
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		strings.NewReader("foo"),
+	)
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "foo", rec.Body.String())
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		strings.NewReader("foobar"),
+	)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "Request Entity Too Large", rec.Body.String())
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/",
+		ioutil.NopCloser(strings.NewReader("foobar")),
+	)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/large",
+		strings.NewReader("foobar"),
+	)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/unlimited",
+		strings.NewReader("foobarbaz"),
+	)
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "foobarbaz", rec.Body.String())
+
+	a.POST("/small", h).MaxRequestBodyBytes = 1
+	a.Pregases = []Gas{
+		func(next Handler) Handler {
+			return func(req *Request, res *Response) error {
+				b := make([]byte, 2)
+				_, err := io.ReadFull(req.Body, b)
+				if err != nil {
+					return err
+				}
+
+				res.Header.Set("X-Peek", string(b))
+
+				return next(req, res)
+			}
+		},
+	}
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/large",
+		ioutil.NopCloser(strings.NewReader("foobar")),
+	)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "fo", rec.Header().Get("X-Peek"))
+	assert.Equal(t, "obar", rec.Body.String())
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/small",
+		ioutil.NopCloser(strings.NewReader("foo")),
+	)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	a = New()
+	a.MaxRequestBodyBytes = 10
+
+	child := New()
+	child.POST("/up", h)
+	child.POST("/large", h).MaxRequestBodyBytes = 100
+	child.POST("/small", h).MaxRequestBodyBytes = 4
+	a.Mount("/c", child)
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/c/up",
+		ioutil.NopCloser(strings.NewReader(strings.Repeat("a", 1000))),
+	)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/c/up",
+		ioutil.NopCloser(strings.NewReader("foobar")),
+	)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/c/large",
+		ioutil.NopCloser(strings.NewReader(strings.Repeat("a", 20))),
+	)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req = httptest.NewRequest(
+		http.MethodPost,
+		"/c/small",
+		ioutil.NopCloser(strings.NewReader("foobar")),
+	)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestRequestMultipartFormLimits(t *testing.T) {
+	a := New()
+	a.MultipartFormMaxFiles = 1
+	a.MultipartFormMaxFields = 1
+
+	type form struct {
+		Foo string `param:"foo"`
+	}
+
+	a.POST("/", func(req *Request, res *Response) error {
+		f := form{}
+		if err := req.Bind(&f); err != nil {
+			return err
+		}
+
+		return res.WriteString(f.Foo)
+	})
+
+	newRequest := func(files, fields int) *http.Request {
+		buf := &bytes.Buffer{}
+		mw := multipart.NewWriter(buf)
+		for i := 0; i < fields; i++ {
+			mw.WriteField("foo", "bar")
+		}
+
+		for i := 0; i < files; i++ {
+			fw, _ := mw.CreateFormFile("file", "file.txt")
+			fw.Write([]byte("foobar"))
+		}
+
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/", buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	a.server.ServeHTTP(rec, newRequest(1, 1))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "bar", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, newRequest(2, 1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, newRequest(1, 2))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestRequestMultipartReader(t *testing.T) {
+	a := New()
+	a.MultipartFormMaxFiles = 1
+
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	mw.WriteField("foo", "bar")
+	fw, _ := mw.CreateFormFile("file", "file.txt")
+	fw.Write([]byte("foobar"))
+	fw, _ = mw.CreateFormFile("file", "file2.txt")
+	fw.Write([]byte("foobar2"))
+	mw.Close()
+
+	req, res, _ := fakeRRCycle(a, http.MethodPost, "/?foo=baz", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	rmr, err := req.MultipartReader()
+	assert.NoError(t, err)
+	assert.NotNil(t, rmr)
+
+	_, err = req.MultipartReader()
+	assert.Error(t, err)
+
+	rmp, err := rmr.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", rmp.Name)
+	assert.False(t, rmp.IsFile())
+
+	b, err := ioutil.ReadAll(rmp)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(b))
+
+	rmp, err = rmr.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "file", rmp.Name)
+	assert.Equal(t, "file.txt", rmp.Filename)
+	assert.Equal(t, "application/octet-stream", rmp.ContentType)
+	assert.True(t, rmp.IsFile())
+
+	b, err = ioutil.ReadAll(rmp)
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", string(b))
+
+	rmp, err = rmr.NextPart()
+	assert.Nil(t, rmp)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Status)
+
+	assert.Len(t, req.Param("foo").Values, 1)
+	assert.Equal(t, "baz", req.QueryParam("foo").String())
+	assert.Nil(t, req.FormParam("foo"))
+
+	buf = &bytes.Buffer{}
+	mw = multipart.NewWriter(buf)
+	mw.WriteField("foo", "bar")
+	mw.Close()
+
+	req, _, _ = fakeRRCycle(a, http.MethodPost, "/buckets/qux?foo=baz", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("X-Foo", "bar")
+	req.routeParamNames = []string{"bucket"}
+	req.routeParamValues = []string{"qux"}
+
+	assert.Equal(t, "qux", req.RouteParam("bucket").String())
+	assert.Equal(t, "baz", req.QueryParam("foo").String())
+	assert.Equal(t, "bar", req.HeaderParam("X-Foo").String())
+
+	rmr, err = req.MultipartReader()
+	assert.NoError(t, err)
+
+	rmp, err = rmr.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", rmp.Name)
+
+	req, _, _ = fakeRRCycle(a, http.MethodGet, "/", nil)
+	_, err = req.MultipartReader()
+	assert.Equal(t, http.ErrNotMultipart, err)
+}
+
+func TestRequestTrustedProxies(t *testing.T) {
+	a := New()
+
+	req, _, _ := fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.hr.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	assert.Equal(t, "10.0.0.1:1234", req.ClientAddress())
+
+	a.TrustedProxies = []string{"10.0.0.0/8", "192.168.0.1"}
+
+	assert.Equal(t, "1.1.1.1", req.ClientAddress())
+
+	req.Header.Set("X-Forwarded-For", "6.6.6.6, 1.1.1.1, 192.168.0.1")
+	assert.Equal(t, "1.1.1.1", req.ClientAddress())
+
+	req.Header.Set("X-Forwarded-For", "10.0.0.2, 192.168.0.1")
+	assert.Equal(t, "10.0.0.2", req.ClientAddress())
+
+	req.Header.Set(
+		"Forwarded",
+		`for=6.6.6.6, for="[2001:db8::1]:4711";proto=https;`+
+			`host=example.com, for=10.0.0.2`,
+	)
+	assert.Equal(t, "2001:db8::1", req.ClientAddress())
+
+	hr := httptest.NewRequest(http.MethodGet, "/", nil)
+	hr.RemoteAddr = "10.0.0.1:1234"
+	hr.Header = req.Header
+	req.SetHTTPRequest(hr)
+	assert.Equal(t, "https", req.Scheme)
+	assert.Equal(t, "example.com", req.Authority)
+
+	hr = httptest.NewRequest(http.MethodGet, "/", nil)
+	hr.RemoteAddr = "10.0.0.1:1234"
+	hr.Header.Set("X-Forwarded-For", "1.1.1.1")
+	hr.Header.Set("X-Forwarded-Proto", "https")
+	hr.Header.Set("X-Forwarded-Host", "example.com")
+	req.SetHTTPRequest(hr)
+	assert.Equal(t, "1.1.1.1", req.ClientAddress())
+	assert.Equal(t, "https", req.Scheme)
+	assert.Equal(t, "example.com", req.Authority)
+
+	hr = httptest.NewRequest(http.MethodGet, "/", nil)
+	hr.RemoteAddr = "10.0.0.1:1234"
+	hr.Header.Set("X-Forwarded-For", "1.1.1.1")
+	hr.Header.Set("X-Forwarded-Proto", "https, http")
+	hr.Header.Set("X-Forwarded-Host", "evil.com, good.com")
+	req.SetHTTPRequest(hr)
+	assert.Equal(t, "1.1.1.1", req.ClientAddress())
+	assert.Equal(t, "http", req.Scheme)
+	assert.Equal(t, "good.com", req.Authority)
+
+	hr = httptest.NewRequest(http.MethodGet, "/", nil)
+	hr.RemoteAddr = "10.0.0.1:1234"
+	hr.Header.Set("X-Forwarded-For", "6.6.6.6, 1.1.1.1, 192.168.0.1")
+	hr.Header.Add("X-Forwarded-Proto", "http, https")
+	hr.Header.Add("X-Forwarded-Proto", "http")
+	hr.Header.Set("X-Forwarded-Host", "evil.com, good.com, internal")
+	req.SetHTTPRequest(hr)
+	assert.Equal(t, "1.1.1.1", req.ClientAddress())
+	assert.Equal(t, "https", req.Scheme)
+	assert.Equal(t, "good.com", req.Authority)
+
+	hr = httptest.NewRequest(http.MethodGet, "/", nil)
+	hr.RemoteAddr = "10.0.0.1:1234"
+	hr.Header.Set("X-Forwarded-For", "6.6.6.6, 1.1.1.1")
+	hr.Header.Set("X-Forwarded-Host", "good.com")
+	req.SetHTTPRequest(hr)
+	assert.Equal(t, "1.1.1.1", req.ClientAddress())
+	assert.Equal(t, "http", req.Scheme)
+	assert.Equal(t, "good.com", req.Authority)
+
+	hr = httptest.NewRequest(http.MethodGet, "/", nil)
+	hr.RemoteAddr = "6.6.6.6:1234"
+	hr.Header.Set("X-Forwarded-For", "1.1.1.1")
+	hr.Header.Set("X-Forwarded-Proto", "https")
+	hr.Header.Set("X-Forwarded-Host", "evil.example.com")
+	req.SetHTTPRequest(hr)
+	assert.Equal(t, "6.6.6.6:1234", req.ClientAddress())
+	assert.Equal(t, "http", req.Scheme)
+	assert.Equal(t, "example.com", req.Authority)
+}
+
+func TestRequestNegotiate(t *testing.T) {
+	a := New()
+
+	req, _, _ := fakeRRCycle(a, http.MethodGet, "/", nil)
+	assert.Equal(
+		t,
+		"application/json",
+		req.Negotiate("application/json", "application/msgpack"),
+	)
+	assert.Empty(t, req.Negotiate())
+
+	req.Header.Set(
+		"Accept",
+		"application/json;q=0.5, application/msgpack, */*;q=0.1",
+	)
+	assert.Equal(
+		t,
+		"application/msgpack",
+		req.Negotiate("application/json", "application/msgpack"),
+	)
+	assert.Equal(t, "text/html", req.Negotiate("text/html"))
+
+	req.Header.Set("Accept", "text/*, application/json;q=0")
+	assert.Equal(
+		t,
+		"text/plain",
+		req.Negotiate("application/json", "text/plain"),
+	)
+	assert.Empty(t, req.Negotiate("application/json"))
+
+	req.Header.Set("Accept-Charset", "iso-8859-1;q=0.5, UTF-8")
+	assert.Equal(t, "utf-8", req.NegotiateCharset("iso-8859-1", "utf-8"))
+
+	req.Header.Set("Accept-Encoding", "gzip;q=0.8, br, *;q=0.1")
+	assert.Equal(t, "br", req.NegotiateEncoding("gzip", "br"))
+	assert.Equal(t, "deflate", req.NegotiateEncoding("deflate"))
+
+	req.Header.Set("Accept-Encoding", "gzip, *;q=0")
+	assert.Empty(t, req.NegotiateEncoding("deflate"))
+}
@@ -6,8 +6,6 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/binary"
-	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"html/template"
@@ -28,16 +26,13 @@ import (
 	"time"
 	"unsafe"
 
-	"github.com/BurntSushi/toml"
 	"github.com/aofei/mimesniffer"
 	"github.com/cespare/xxhash"
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/websocket"
-	"github.com/vmihailenco/msgpack"
 	"golang.org/x/net/html"
 	"golang.org/x/net/http/httpguts"
 	"golang.org/x/net/http2"
-	yaml "gopkg.in/yaml.v2"
 )
 
 // Response is an HTTP response.
@@ -239,102 +234,104 @@ func (r *Response) WriteString(s string) error {
 	return r.Write(strings.NewReader(s))
 }
 
-// WriteJSON writes an "application/json" content encoded from the v to the
-// client.
-func (r *Response) WriteJSON(v interface{}) error {
-	var (
-		b   []byte
-		err error
-	)
+// WriteEncoded writes a content encoded from the v by the registered `Codec`
+// for the media type of the contentType (see the `Air.RegisterCodec`) to the
+// client. The Content-Type header of the r is set to the contentType.
+func (r *Response) WriteEncoded(contentType string, v interface{}) error {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
 
-	if r.Air.DebugMode {
-		b, err = json.MarshalIndent(v, "", "\t")
-	} else {
-		b, err = json.Marshal(v)
+	c := r.Air.Codec(mt)
+	if c == nil {
+		return fmt.Errorf("air: codec not found for %s", mt)
 	}
 
-	if err != nil {
+	buf := bytes.Buffer{}
+	if err := c.Encode(&buf, v); err != nil {
 		return err
 	}
 
-	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("Content-Type", contentType)
 
-	return r.Write(bytes.NewReader(b))
+	return r.Write(bytes.NewReader(buf.Bytes()))
+}
+
+// WriteJSON writes an "application/json" content encoded from the v to the
+// client.
+func (r *Response) WriteJSON(v interface{}) error {
+	return r.WriteEncoded("application/json; charset=utf-8", v)
 }
 
 // WriteXML writes an "application/xml" content encoded from the v to the
 // client.
 func (r *Response) WriteXML(v interface{}) error {
-	var (
-		b   []byte
-		err error
-	)
-
-	if r.Air.DebugMode {
-		b, err = xml.MarshalIndent(v, "", "\t")
-	} else {
-		b, err = xml.Marshal(v)
-	}
-
-	if err != nil {
-		return err
-	}
-
-	r.Header.Set("Content-Type", "application/xml; charset=utf-8")
-
-	return r.Write(strings.NewReader(xml.Header + string(b)))
+	return r.WriteEncoded("application/xml; charset=utf-8", v)
 }
 
 // WriteProtobuf writes an "application/protobuf" content encoded from the v to
 // the client.
 func (r *Response) WriteProtobuf(v interface{}) error {
-	b, err := proto.Marshal(v.(proto.Message))
-	if err != nil {
-		return err
-	}
-
-	r.Header.Set("Content-Type", "application/protobuf")
-
-	return r.Write(bytes.NewReader(b))
+	return r.WriteEncoded("application/protobuf", v)
 }
 
 // WriteMsgpack writes an "application/msgpack" content encoded from the v to
 // the client.
 func (r *Response) WriteMsgpack(v interface{}) error {
-	b, err := msgpack.Marshal(v)
-	if err != nil {
-		return err
-	}
-
-	r.Header.Set("Content-Type", "application/msgpack")
-
-	return r.Write(bytes.NewReader(b))
+	return r.WriteEncoded("application/msgpack", v)
 }
 
 // WriteTOML writes an "application/toml" content encoded from the v to the
 // client.
 func (r *Response) WriteTOML(v interface{}) error {
-	buf := bytes.Buffer{}
-	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
-		return err
-	}
-
-	r.Header.Set("Content-Type", "application/toml; charset=utf-8")
-
-	return r.Write(bytes.NewReader(buf.Bytes()))
+	return r.WriteEncoded("application/toml; charset=utf-8", v)
 }
 
 // WriteYAML writes an "application/yaml" content encoded from the v to the
 // client.
 func (r *Response) WriteYAML(v interface{}) error {
-	buf := bytes.Buffer{}
-	if err := yaml.NewEncoder(&buf).Encode(v); err != nil {
-		return err
+	return r.WriteEncoded("application/yaml; charset=utf-8", v)
+}
+
+// WriteNegotiated writes a content encoded from the v to the client in the MIME
+// type negotiated based on the Accept header (see the `Request.Negotiate`).
+//
+// The offered MIME types are the ones of the registered codecs (see the
+// `Air.RegisterCodec`) in order, except the "application/protobuf" if the v is
+// not a `proto.Message`. If none of them is acceptable, the status of the r is
+// set to 406 and an error is returned.
+func (r *Response) WriteNegotiated(v interface{}) error {
+	_, isProtoMessage := v.(proto.Message)
+
+	var offers []string
+	for _, mt := range r.Air.codecRegistry.registeredMIMETypes() {
+		if mt != "application/protobuf" || isProtoMessage {
+			offers = append(offers, mt)
+		}
 	}
 
-	r.Header.Set("Content-Type", "application/yaml; charset=utf-8")
+	if !httpguts.HeaderValuesContainsToken(r.Header["Vary"], "Accept") {
+		r.Header.Add("Vary", "Accept")
+	}
 
-	return r.Write(bytes.NewReader(buf.Bytes()))
+	switch mt := r.req.Negotiate(offers...); mt {
+	case "":
+	case "application/json":
+		return r.WriteJSON(v)
+	case "application/xml":
+		return r.WriteXML(v)
+	case "application/toml":
+		return r.WriteTOML(v)
+	case "application/yaml":
+		return r.WriteYAML(v)
+	default:
+		return r.WriteEncoded(mt, v)
+	}
+
+	r.Status = http.StatusNotAcceptable
+
+	return errors.New(http.StatusText(r.Status))
 }
 
 // WriteHTML writes the h as a "text/html" content to the client.
@@ -0,0 +1,37 @@
+package air
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseWriteNegotiated(t *testing.T) {
+	a := New()
+
+	v := map[string]string{
+		"foo": "bar",
+	}
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/", nil)
+	assert.NoError(t, res.WriteNegotiated(v))
+	assert.Equal(
+		t,
+		"application/json; charset=utf-8",
+		rec.Header().Get("Content-Type"),
+	)
+	assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+	assert.Equal(t, `{"foo":"bar"}`, rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json;q=0.9, application/msgpack")
+	assert.NoError(t, res.WriteNegotiated(v))
+	assert.Equal(t, "application/msgpack", rec.Header().Get("Content-Type"))
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/protobuf")
+	assert.Error(t, res.WriteNegotiated(v))
+	assert.Equal(t, http.StatusNotAcceptable, res.Status)
+	assert.False(t, res.Written)
+}
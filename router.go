@@ -1,10 +1,16 @@
 package air
 
 import (
+	"fmt"
+	"net"
+	"net/http"
 	"net/url"
 	ppath "path"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // router is a registry of all registered routes.
@@ -12,33 +18,51 @@ type router struct {
 	sync.Mutex
 
 	a                    *Air
-	routeTree            *routeNode
+	routeTable           atomic.Value
 	registeredRoutes     map[string]bool
-	maxRouteParams       int
+	routes               []*Route
+	groups               []*routeGroup
+	routeParamRegexps    map[string]*regexp.Regexp
 	routeParamValuesPool *sync.Pool
 }
 
 // newRouter returns a new instance of the `router` with the a.
 func newRouter(a *Air) *router {
 	r := &router{
-		a: a,
-		routeTree: &routeNode{
-			handlers: map[string]Handler{},
-		},
-		registeredRoutes: map[string]bool{},
-	}
-	r.routeParamValuesPool = &sync.Pool{
-		New: func() interface{} {
-			return make([]string, r.maxRouteParams)
+		a:                 a,
+		registeredRoutes:  map[string]bool{},
+		routeParamRegexps: map[string]*regexp.Regexp{},
+		routeParamValuesPool: &sync.Pool{
+			New: func() interface{} {
+				return []string{}
+			},
 		},
 	}
+	r.routeTable.Store(newRouteTable(nil, nil))
 
 	return r
 }
 
 // register registers a new route for the method and the path with the matching
 // h in the r with the optional route-level gases.
-func (r *router) register(method, path string, h Handler, gases ...Gas) {
+func (r *router) register(
+	method string,
+	path string,
+	h Handler,
+	gases ...Gas,
+) *Route {
+	return r.registerHost("", method, path, h, gases...)
+}
+
+// registerHost is just like the `register` of the r, but the new route will be
+// registered for the host. An empty host means any host.
+func (r *router) registerHost(
+	host string,
+	method string,
+	path string,
+	h Handler,
+	gases ...Gas,
+) *Route {
 	r.Lock()
 	defer r.Unlock()
 
@@ -48,7 +72,71 @@ func (r *router) register(method, path string, h Handler, gases ...Gas) {
 		panic("air: route handler cannot be nil")
 	}
 
+	paramNames := []string{}
+	if host = strings.ToLower(host); host != "" {
+		paramNames = append(
+			paramNames,
+			newHostRouteTree(host).paramNames...,
+		)
+	}
+
+	path, paramConstraints := splitRouteParamConstraints(path)
 	path = ppath.Clean(path)
+	rt := &Route{
+		Method: method,
+		Path:   joinRouteParamConstraints(path, paramConstraints),
+		Host:   host,
+
+		handler: h,
+		gases:   gases,
+	}
+
+	for i, v := range routePathVariants(path, paramConstraints) {
+		key, pns := r.plan(rt, v.path, v.paramConstraints, paramNames)
+		if i == 0 {
+			rt.ParamNames = pns
+		}
+
+		if host != "" {
+			key = host + " " + key
+		}
+
+		if r.registeredRoutes[key] ||
+			stringSliceContains(rt.keys, key) {
+			panic("air: route already exists")
+		}
+
+		rt.keys = append(rt.keys, key)
+	}
+
+	for _, key := range rt.keys {
+		r.registeredRoutes[key] = true
+	}
+
+	r.routes = append(r.routes, rt)
+	r.routeTable.Store(newRouteTable(r.routes, r.groups))
+
+	return rt
+}
+
+// plan plans the insertions of the rt into a route tree for the path with the
+// param constraints pcs. The paramNames are the names of the params that
+// precede the path (e.g. the host params). It returns the key of the path that
+// identifies it in the `registeredRoutes` and the names of all params.
+func (r *router) plan(
+	rt *Route,
+	path string,
+	pcs []string,
+	paramNames []string,
+) (string, []string) {
+	paramNames = append([]string{}, paramNames...)
+	paramRegexps := make([]*regexp.Regexp, len(pcs))
+	for i, pc := range pcs {
+		if pc != "" {
+			paramRegexps[i] = r.routeParamRegexp(pc)
+		}
+	}
+
 	path = url.PathEscape(path)
 	path = strings.Replace(path, "%2F", "/", -1)
 	path = strings.Replace(path, "%2A", "*", -1)
@@ -62,30 +150,54 @@ func (r *router) register(method, path string, h Handler, gases ...Gas) {
 					"path must be separated by /")
 			}
 		}
-	} else if strings.Contains(path, "*") {
-		if strings.Count(path, "*") > 1 {
-			panic("air: only one * is allowed in route path")
-		} else if path[len(path)-1] != '*' {
-			panic("air: * can only appear at end of route path")
-		} else if strings.Contains(
-			path[strings.LastIndex(path, "/"):],
-			":",
-		) {
-			panic("air: adjacent param name and * in route path " +
-				"must be separated by /")
+	}
+
+	if strings.Contains(path, "*") {
+		ss := strings.Split(path, "/")
+		for i, s := range ss {
+			j := strings.IndexByte(s, '*')
+			if j < 0 {
+				continue
+			} else if j > 0 && i < len(ss)-1 {
+				panic("air: * must be at beginning of " +
+					"segment unless in last segment of " +
+					"route path")
+			} else if strings.Contains(s, ":") {
+				panic("air: adjacent param name and * in " +
+					"route path must be separated by /")
+			} else if strings.Count(s, "*") > 1 {
+				panic("air: adjacent * in route path must be " +
+					"separated by /")
+			}
 		}
 	}
 
-	routeName := method + path
-	for i, l := len(method), len(routeName); i < l; i++ {
-		if routeName[i] == ':' {
+	key := rt.Method + path
+	for i, l, pi := len(rt.Method), len(key), 0; i < l; i++ {
+		if key[i] == '*' {
+			j := strings.IndexByte(key[i:], '/')
+			if j < 0 {
+				key = key[:i+1]
+				break
+			}
+
+			key = key[:i+1] + key[i+j:]
+			l = len(key)
+		} else if key[i] == ':' {
 			j := i + 1
 
-			for ; i < l && routeName[i] != '/'; i++ {
+			for ; i < l && key[i] != '/'; i++ {
+			}
+
+			pc := ""
+			if pi < len(pcs) && pcs[pi] != "" {
+				pc = "<" + pcs[pi] + ">"
 			}
 
-			routeName = routeName[:j] + routeName[i:]
-			i, l = j, len(routeName)
+			pi++
+
+			key = key[:j] + pc + key[i:]
+			i, l = j+len(pc), len(key)
 
 			if i == l {
 				break
@@ -93,110 +205,257 @@ func (r *router) register(method, path string, h Handler, gases ...Gas) {
 		}
 	}
 
-	if r.registeredRoutes[routeName] {
-		panic("air: route already exists")
-	} else {
-		r.registeredRoutes[routeName] = true
-	}
-
-	rh := func(req *Request, res *Response) error {
-		h := h
-		for i := len(gases) - 1; i >= 0; i-- {
-			h = gases[i](h)
+	addParamName := func(pn string) {
+		if stringSliceContains(paramNames, pn) {
+			panic("air: route path cannot have duplicate param " +
+				"names")
 		}
 
-		return h(req, res)
+		paramNames = append(paramNames, pn)
+	}
+
+	addInsertion := func(
+		path string,
+		nt routeNodeType,
+		paramNames []string,
+		handled bool,
+	) {
+		rt.insertions = append(rt.insertions, routeInsertion{
+			path:         path,
+			nt:           nt,
+			paramNames:   paramNames,
+			paramRegexps: paramRegexps,
+			handled:      handled,
+		})
 	}
 
-	paramNames := []string{}
 	for i, l := 0, len(path); i < l; i++ {
 		if path[i] == ':' {
 			j := i + 1
 
-			r.insert(
-				method,
-				path[:i],
-				nil,
-				routeNodeTypeStatic,
-				nil,
-			)
+			addInsertion(path[:i], routeNodeTypeStatic, nil, false)
 
 			for ; i < l && path[i] != '/'; i++ {
 			}
 
-			paramName := path[j:i]
-
-			for _, pn := range paramNames {
-				if pn == paramName {
-					panic("air: route path cannot have " +
-						"duplicate param names")
-				}
-			}
-
-			paramNames = append(paramNames, paramName)
+			addParamName(path[j:i])
 			path = path[:j] + path[i:]
 
 			if i, l = j, len(path); i == l {
-				r.insert(
-					method,
+				addInsertion(
 					path,
-					rh,
 					routeNodeTypeParam,
 					paramNames,
+					true,
 				)
-				return
+				return key, paramNames
 			}
 
-			r.insert(
-				method,
+			addInsertion(
 				path[:i],
-				nil,
 				routeNodeTypeParam,
 				paramNames,
+				false,
 			)
 		} else if path[i] == '*' {
-			r.insert(
-				method,
-				path[:i],
-				nil,
-				routeNodeTypeStatic,
-				nil,
-			)
-			paramNames = append(paramNames, "*")
-			r.insert(
-				method,
+			addInsertion(path[:i], routeNodeTypeStatic, nil, false)
+
+			j := strings.IndexByte(path[i:], '/')
+			if j < 0 {
+				j = len(path) - i
+			}
+
+			if pn := path[i+1 : i+j]; pn != "" {
+				addParamName(pn)
+			} else {
+				addParamName("*")
+			}
+
+			if path = path[:i+1] + path[i+j:]; i+1 == len(path) {
+				addInsertion(
+					path,
+					routeNodeTypeAny,
+					paramNames,
+					true,
+				)
+				return key, paramNames
+			}
+
+			l = len(path)
+			addInsertion(
 				path[:i+1],
-				rh,
 				routeNodeTypeAny,
 				paramNames,
+				false,
+			)
+		}
+	}
+
+	addInsertion(path, routeNodeTypeStatic, paramNames, true)
+
+	return key, paramNames
+}
+
+// remove removes the rt from the r. It does nothing if the rt is not registered
+// in the r.
+func (r *router) remove(rt *Route) {
+	r.Lock()
+	defer r.Unlock()
+
+	for i, v := range r.routes {
+		if v == rt {
+			r.routes = append(r.routes[:i:i], r.routes[i+1:]...)
+			for _, key := range rt.keys {
+				delete(r.registeredRoutes, key)
+			}
+
+			r.routeTable.Store(newRouteTable(r.routes, r.groups))
+			break
+		}
+	}
+}
+
+// replaceHandler replaces the handler of the rt in the r with the h. It does
+// nothing if the rt is not registered in the r.
+func (r *router) replaceHandler(rt *Route, h Handler) {
+	if h == nil {
+		panic("air: route handler cannot be nil")
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for _, v := range r.routes {
+		if v == rt {
+			rt.handler = h
+			r.routeTable.Store(newRouteTable(r.routes, r.groups))
+			break
+		}
+	}
+}
+
+// loadRouteTable returns the current `routeTable` of the r.
+func (r *router) loadRouteTable() *routeTable {
+	return r.routeTable.Load().(*routeTable)
+}
+
+// routeParamValues returns a slice from the `r.routeParamValuesPool` that is
+// long enough to hold the route param values of the routes in the t.
+func (r *router) routeParamValues(t *routeTable) []string {
+	vs := r.routeParamValuesPool.Get().([]string)
+	if len(vs) < t.maxRouteParams {
+		vs = make([]string, t.maxRouteParams)
+	}
+
+	return vs
+}
+
+// routeTable is an immutable snapshot of the route trees built from the
+// registered routes of a `router`, and of its registered groups. A new one will
+// be built whenever the registered routes or groups change, so that the
+// `router.route` can be lock-free.
+type routeTable struct {
+	routeTree      *routeNode
+	hostRouteTrees []*hostRouteTree
+	maxRouteParams int
+	groups         []*routeGroup
+	groupGases     bool
+}
+
+// newRouteTable returns a new instance of the `routeTable` with the routes and
+// the groups.
+func newRouteTable(routes []*Route, groups []*routeGroup) *routeTable {
+	t := &routeTable{
+		routeTree: &routeNode{
+			handlers: map[string]Handler{},
+			routes:   map[string]*Route{},
+		},
+		groups: make([]*routeGroup, 0, len(groups)),
+	}
+
+	for _, rg := range groups {
+		rgc := *rg
+		rgc.gases = append([]Gas(nil), rg.gases...)
+		t.groups = append(t.groups, &rgc)
+		t.groupGases = t.groupGases || len(rgc.gases) > 0
+	}
+
+	sort.SliceStable(t.groups, func(i, j int) bool {
+		gi, gj := t.groups[i], t.groups[j]
+		li, lj := len(gi.prefixSegments), len(gj.prefixSegments)
+		if li != lj {
+			return li > lj
+		}
+
+		return gi.hostRouteTree != nil && gj.hostRouteTree == nil
+	})
+
+	for _, rt := range routes {
+		tree := t.routeTree
+		if rt.Host != "" {
+			tree = t.hostRouteTree(rt.Host).routeTree
+		}
+
+		h, gases := rt.handler, rt.gases
+		rh := func(req *Request, res *Response) error {
+			h := h
+			for i := len(gases) - 1; i >= 0; i-- {
+				h = gases[i](h)
+			}
+
+			return h(req, res)
+		}
+
+		for _, ri := range rt.insertions {
+			var (
+				h  Handler
+				hr *Route
+			)
+
+			if ri.handled {
+				h, hr = rh, rt
+			}
+
+			t.insert(
+				tree,
+				rt.Method,
+				ri.path,
+				h,
+				hr,
+				ri.nt,
+				ri.paramNames,
+				ri.paramRegexps,
 			)
-			return
 		}
 	}
 
-	r.insert(method, path, rh, routeNodeTypeStatic, paramNames)
+	return t
 }
 
-// insert inserts a new route into the `r.routeTree`.
-func (r *router) insert(
+// insert inserts a new route into the tree of the t. The rt is the `Route`
+// that the h belongs to.
+func (t *routeTable) insert(
+	tree *routeNode,
 	method string,
 	path string,
 	h Handler,
+	rt *Route,
 	nt routeNodeType,
 	paramNames []string,
+	paramRegexps []*regexp.Regexp,
 ) {
-	if l := len(paramNames); l > r.maxRouteParams {
-		r.maxRouteParams = l
+	if l := len(paramNames); l > t.maxRouteParams {
+		t.maxRouteParams = l
 	}
 
 	var (
-		s  = path        // Search
-		cn = r.routeTree // Current node
-		nn *routeNode    // Next node
-		sl int           // Search length
-		pl int           // Prefix length
-		ll int           // LCP length
-		ml int           // Minimum length of sl and pl
+		s  = path     // Search
+		cn = tree     // Current node
+		nn *routeNode // Next node
+		sl int        // Search length
+		pl int        // Prefix length
+		ll int        // LCP length
+		ml int        // Minimum length of sl and pl
 	)
 
 	for {
@@ -218,31 +477,44 @@ func (r *router) insert(
 			cn.prefix = s
 			cn.paramNames = paramNames
 			if h != nil {
-				cn.handlers[method] = h
+				cn.setHandler(method, h, rt)
 			}
 		} else if ll < pl { // Split node
 			nn = &routeNode{
-				label:      cn.prefix[ll],
-				nType:      cn.nType,
-				prefix:     cn.prefix[ll:],
-				children:   cn.children,
-				paramNames: cn.paramNames,
-				handlers:   cn.handlers,
+				label:          cn.prefix[ll],
+				nType:          cn.nType,
+				prefix:         cn.prefix[ll:],
+				staticIndices:  cn.staticIndices,
+				staticChildren: cn.staticChildren,
+				paramChildren:  cn.paramChildren,
+				anyChild:       cn.anyChild,
+				paramNames:     cn.paramNames,
+				paramRegexp:    cn.paramRegexp,
+				handlers:       cn.handlers,
+				routes:         cn.routes,
+				pattern:        cn.pattern,
 			}
 
 			// Reset current node.
 			cn.label = cn.prefix[0]
 			cn.nType = routeNodeTypeStatic
 			cn.prefix = cn.prefix[:ll]
-			cn.children = []*routeNode{nn}
+			cn.staticIndices = ""
+			cn.staticChildren = nil
+			cn.paramChildren = nil
+			cn.anyChild = nil
+			cn.addChild(nn)
 			cn.paramNames = nil
+			cn.paramRegexp = nil
 			cn.handlers = map[string]Handler{}
+			cn.routes = map[string]*Route{}
+			cn.pattern = ""
 
 			if ll == sl { // At current node
 				cn.nType = nt
 				cn.paramNames = paramNames
 				if h != nil {
-					cn.handlers[method] = h
+					cn.setHandler(method, h, rt)
 				}
 			} else { // Create child node
 				nn = &routeNode{
@@ -251,16 +523,37 @@ func (r *router) insert(
 					prefix:     s[ll:],
 					paramNames: paramNames,
 					handlers:   map[string]Handler{},
+					routes:     map[string]*Route{},
 				}
+				if nn.label == ':' {
+					pi := strings.Count(
+						path[:len(path)-len(s)+ll],
+						":",
+					)
+					nn.paramRegexp = paramRegexps[pi]
+				}
+
 				if h != nil {
-					nn.handlers[method] = h
+					nn.setHandler(method, h, rt)
 				}
 
-				cn.children = append(cn.children, nn)
+				cn.addChild(nn)
 			}
 		} else if ll < sl {
 			s = s[ll:]
-			if nn = cn.childByLabel(s[0]); nn != nil {
+
+			var pre *regexp.Regexp // Param regexp
+			if s[0] == ':' {
+				pre = paramRegexps[strings.Count(
+					path[:len(path)-len(s)],
+					":",
+				)]
+				nn = cn.paramChildByRegexp(pre)
+			} else {
+				nn = cn.childByLabel(s[0])
+			}
+
+			if nn != nil {
 				// Go deeper.
 				cn = nn
 				continue
@@ -268,24 +561,26 @@ func (r *router) insert(
 
 			// Create child node.
 			nn = &routeNode{
-				label:      s[0],
-				nType:      nt,
-				prefix:     s,
-				handlers:   map[string]Handler{},
-				paramNames: paramNames,
+				label:       s[0],
+				nType:       nt,
+				prefix:      s,
+				handlers:    map[string]Handler{},
+				routes:      map[string]*Route{},
+				paramNames:  paramNames,
+				paramRegexp: pre,
 			}
 			if h != nil {
-				nn.handlers[method] = h
+				nn.setHandler(method, h, rt)
 			}
 
-			cn.children = append(cn.children, nn)
+			cn.addChild(nn)
 		} else { // Node already exists
-			if len(cn.paramNames) == 0 {
+			if len(cn.paramNames) == 0 || h != nil {
 				cn.paramNames = paramNames
 			}
 
 			if h != nil {
-				cn.handlers[method] = h
+				cn.setHandler(method, h, rt)
 			}
 		}
 
@@ -295,168 +590,1024 @@ func (r *router) insert(
 
 // route returns a handler registered for the req.
 func (r *router) route(req *Request) Handler {
-	var (
-		s, _ = splitPathQuery(req.Path) // Search
-		cn   = r.routeTree              // Current node
-		nn   *routeNode                 // Next node
-		nnt  routeNodeType              // Next node type
-		sn   *routeNode                 // Saved node
-		ss   string                     // Saved search
-		sl   int                        // Search length
-		pl   int                        // Prefix length
-		ll   int                        // LCP length
-		ml   int                        // Minimum length of sl and pl
-		i    int                        // Index
-		pi   int                        // Param index
-	)
+	t := r.loadRouteTable()
+	host := normalizeHost(req.Authority)
 
-	// Search order: static route > param route > any route.
-	for {
-		if s == "" {
+	s, _ := splitPathQuery(req.Path)
+	for i := 0; ; {
+		tree, pi, ni := r.nextRouteTree(req, t, host, i)
+		if tree == nil {
 			break
 		}
 
-		for i, sl = 1, len(s); i < sl && s[i] == '/'; i++ {
+		if cn := r.match(req, t, tree, s, pi, req.Method); cn != nil {
+			h, rt := cn.handlers[req.Method], cn.routes[req.Method]
+			if h == nil {
+				h = cn.handlers[http.MethodGet]
+				rt = cn.routes[http.MethodGet]
+			}
+
+			req.routeParamNames = cn.paramNames
+			req.route = rt
+			req.routePattern = rt.Path
+
+			return h
 		}
 
-		s = s[i-1:]
+		i = ni
+	}
+
+	for i := 0; ; {
+		tree, pi, ni := r.nextRouteTree(req, t, host, i)
+		if tree == nil {
+			break
+		}
 
-		pl = 0
-		ll = 0
+		cn := r.match(req, t, tree, s, pi, "")
+		if cn == nil {
+			i = ni
+			continue
+		}
 
-		if cn.label != ':' {
-			pl = len(cn.prefix)
+		req.routePattern = cn.pattern
 
-			ml = pl
-			if sl = len(s); sl < ml {
-				ml = sl
-			}
+		ms := map[string]bool{}
+		tree.collectMethods(s, ms)
 
-			for ; ll < ml && s[ll] == cn.prefix[ll]; ll++ {
+		allow := allowedMethods(ms)
+		if req.Method == http.MethodOptions {
+			return func(req *Request, res *Response) error {
+				res.Header.Set("Allow", allow)
+				return nil
 			}
 		}
 
-		if ll != pl {
-			goto Struggle
+		mnah := r.a.methodNotAllowedHandler(req)
+
+		return func(req *Request, res *Response) error {
+			res.Header.Set("Allow", allow)
+			return mnah(req, res)
+		}
+	}
+
+	return r.notFoundHandler(req, t, host)
+}
+
+// match returns the node in the rn and its descendants that matches the s and
+// has a handler for the method with the highest priority (static route > param
+// route > any route at every level), or nil if not found. An empty method means
+// any method. The values of the matched params will be filled into the
+// `req.routeParamValues` starting from the pi.
+//
+// A node matches the s when the s is completely consumed. Every possible path
+// is explored until a matching node is found.
+func (r *router) match(
+	req *Request,
+	t *routeTable,
+	rn *routeNode,
+	s string,
+	pi int,
+	method string,
+) *routeNode {
+	switch rn.nType {
+	case routeNodeTypeStatic:
+		for len(s) > 1 && s[0] == '/' && s[1] == '/' {
+			s = s[1:]
+		}
+
+		if !strings.HasPrefix(s, rn.prefix) {
+			return nil
+		}
+
+		s = s[len(rn.prefix):]
+	case routeNodeTypeParam:
+		i := strings.IndexByte(s, '/')
+		if i < 0 {
+			i = len(s)
+		}
+
+		if !rn.acceptsParamValue(s[:i]) {
+			return nil
 		}
 
-		if s = s[ll:]; s == "" {
-			if len(cn.handlers) == 0 {
-				if cn.childByType(routeNodeTypeParam) != nil {
-					goto Param
+		if req.routeParamValues == nil {
+			req.routeParamValues = r.routeParamValues(t)
+		}
+
+		req.routeParamValues[pi] = s[:i]
+		pi++
+
+		s = s[i:]
+	case routeNodeTypeAny:
+		if req.routeParamValues == nil {
+			req.routeParamValues = r.routeParamValues(t)
+		}
+
+		if len(rn.staticChildren) > 0 ||
+			len(rn.paramChildren) > 0 ||
+			rn.anyChild != nil {
+			for i := 1; i < len(s); i++ {
+				if s[i] != '/' {
+					continue
 				}
 
-				if cn.childByType(routeNodeTypeAny) != nil {
-					goto Any
+				req.routeParamValues[pi] = s[:i]
+				n := r.matchChildren(
+					req,
+					t,
+					rn,
+					s[i:],
+					pi+1,
+					method,
+				)
+				if n != nil {
+					return n
 				}
 			}
+		}
 
-			break
+		if !rn.handles(method) {
+			return nil
 		}
 
-		// Static node.
-		if nn = cn.child(s[0], routeNodeTypeStatic); nn != nil {
-			// Save next.
-			if pl = len(cn.prefix); pl > 0 &&
-				cn.prefix[pl-1] == '/' {
-				nnt = routeNodeTypeParam
-				sn = cn
-				ss = s
+		req.routeParamValues[pi] = s
+
+		return rn
+	}
+
+	if s == "" && rn.handles(method) {
+		return rn
+	}
+
+	return r.matchChildren(req, t, rn, s, pi, method)
+}
+
+// matchChildren is just like the `match` of the r, but only matches the s with
+// the child nodes of the rn.
+func (r *router) matchChildren(
+	req *Request,
+	t *routeTable,
+	rn *routeNode,
+	s string,
+	pi int,
+	method string,
+) *routeNode {
+	if s != "" {
+		if c := rn.staticChild(s[0]); c != nil {
+			if n := r.match(req, t, c, s, pi, method); n != nil {
+				return n
 			}
+		}
+	}
+
+	for _, c := range rn.paramChildren {
+		if n := r.match(req, t, c, s, pi, method); n != nil {
+			return n
+		}
+	}
+
+	if rn.anyChild != nil {
+		return r.match(req, t, rn.anyChild, s, pi, method)
+	}
+
+	return nil
+}
+
+// nextRouteTree returns the first route tree in the t, starting from the index
+// i, that the req falls under based on the host of its `Request.Authority`. The
+// host route trees are tried in priority order, followed by the host-less route
+// tree. It also returns the number of the host params that have been filled
+// into the `req.routeParamValues` and the index to continue from. The returned
+// route tree is nil if there are no more.
+func (r *router) nextRouteTree(
+	req *Request,
+	t *routeTable,
+	host string,
+	i int,
+) (*routeNode, int, int) {
+	for ; i < len(t.hostRouteTrees); i++ {
+		hrt := t.hostRouteTrees[i]
+		if len(hrt.paramNames) > 0 && req.routeParamValues == nil {
+			req.routeParamValues = r.routeParamValues(t)
+		}
+
+		if hrt.match(host, req.routeParamValues) {
+			return hrt.routeTree, len(hrt.paramNames), i + 1
+		}
+	}
 
-			cn = nn
+	if i == len(t.hostRouteTrees) {
+		return t.routeTree, 0, i + 1
+	}
+
+	return nil, 0, i
+}
+
+// registerGroup registers the g in the r.
+func (r *router) registerGroup(g *Group) {
+	rg := newRouteGroup(g)
+
+	r.Lock()
+	r.groups = append(r.groups, rg)
+	r.routeTable.Store(newRouteTable(r.routes, r.groups))
+	r.Unlock()
+}
+
+// useGroupGases appends the gases to the g for the `Group.Use`. The g will be
+// registered in the r first if it has not been registered yet (e.g. a `Group`
+// literal).
+func (r *router) useGroupGases(g *Group, gases []Gas) {
+	r.Lock()
+	defer r.Unlock()
+
+	var rg *routeGroup
+	for _, v := range r.groups {
+		if v.g == g {
+			rg = v
+			break
+		}
+	}
+
+	if rg == nil {
+		rg = newRouteGroup(g)
+		r.groups = append(r.groups, rg)
+	}
 
+	rg.gases = append(rg.gases, gases...)
+	r.routeTable.Store(newRouteTable(r.routes, r.groups))
+}
+
+// wrapGroupGases wraps the h with the gases of the groups registered in the r
+// that the req falls under (see the `Group.Use`).
+func (r *router) wrapGroupGases(req *Request, h Handler) Handler {
+	t := r.loadRouteTable()
+	if !t.groupGases {
+		return h
+	}
+
+	host := normalizeHost(req.Authority)
+	path, _ := splitPathQuery(req.Path)
+	for _, rg := range t.groups {
+		if len(rg.gases) == 0 || !rg.matches(host, path) {
 			continue
 		}
 
-		// Param node.
-	Param:
-		if nn = cn.childByType(routeNodeTypeParam); nn != nil {
-			// Save next.
-			if pl = len(cn.prefix); pl > 0 &&
-				cn.prefix[pl-1] == '/' {
-				nnt = routeNodeTypeAny
-				sn = cn
-				ss = s
+		for i := len(rg.gases) - 1; i >= 0; i-- {
+			h = rg.gases[i](h)
+		}
+	}
+
+	return h
+}
+
+// closestGroup returns the closest group registered in the r that the req falls
+// under (the one with the most prefix segments, and then the one with a host)
+// and satisfies the f. It returns nil if not found.
+func (r *router) closestGroup(req *Request, f func(*Group) bool) *Group {
+	t := r.loadRouteTable()
+	if len(t.groups) == 0 {
+		return nil
+	}
+
+	host := normalizeHost(req.Authority)
+	path, _ := splitPathQuery(req.Path)
+	for _, rg := range t.groups {
+		if f(rg.g) && rg.matches(host, path) {
+			return rg.g
+		}
+	}
+
+	return nil
+}
+
+// routeGroup is a `Group` registered in a `router`, with its host and prefix
+// matchers precomputed.
+type routeGroup struct {
+	g              *Group
+	hostRouteTree  *hostRouteTree
+	prefixSegments []string
+	gases          []Gas
+}
+
+// newRouteGroup returns a new instance of the `routeGroup` with the g.
+func newRouteGroup(g *Group) *routeGroup {
+	rg := &routeGroup{
+		g: g,
+	}
+
+	if g.Host != "" {
+		rg.hostRouteTree = newHostRouteTree(strings.ToLower(g.Host))
+	}
+
+	prefix, _ := splitRouteParamConstraints(g.Prefix)
+	for _, ps := range strings.Split(prefix, "/") {
+		if ps != "" {
+			rg.prefixSegments = append(rg.prefixSegments, ps)
+		}
+	}
+
+	return rg
+}
+
+// matches reports whether the request with the host and the path falls under
+// the rg. The host must be normalized by the `normalizeHost`.
+func (rg *routeGroup) matches(host, path string) bool {
+	if rg.hostRouteTree != nil && !rg.hostRouteTree.match(host, nil) {
+		return false
+	}
+
+	for i, j := 0, 0; i < len(rg.prefixSegments); i++ {
+		ps := rg.prefixSegments[i]
+		if ps[0] == '*' {
+			return true
+		}
+
+		for ; j < len(path) && path[j] == '/'; j++ {
+		}
+
+		if j == len(path) {
+			return false
+		}
+
+		k := strings.IndexByte(path[j:], '/')
+		if k < 0 {
+			k = len(path) - j
+		}
+
+		s := path[j : j+k]
+		if us, err := url.PathUnescape(s); err == nil {
+			s = us
+		}
+
+		if ps[0] != ':' && ps != s {
+			return false
+		}
+
+		j += k
+	}
+
+	return true
+}
+
+// normalizeHost returns the host of the authority in lowercase without port and
+// trailing dot.
+func normalizeHost(authority string) string {
+	host := authority
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// hostRouteTree returns the `hostRouteTree` of the t for the host. It creates a
+// new one if not found.
+func (t *routeTable) hostRouteTree(host string) *hostRouteTree {
+	for _, hrt := range t.hostRouteTrees {
+		if hrt.host == host {
+			return hrt
+		}
+	}
+
+	hrt := newHostRouteTree(host)
+	t.hostRouteTrees = append(t.hostRouteTrees, hrt)
+	sort.SliceStable(t.hostRouteTrees, func(i, j int) bool {
+		hi, hj := t.hostRouteTrees[i], t.hostRouteTrees[j]
+		if ri, rj := hi.rank(), hj.rank(); ri != rj {
+			return ri < rj
+		}
+
+		return len(hi.labels) > len(hj.labels)
+	})
+
+	return hrt
+}
+
+// newHostRouteTree returns a new instance of the `hostRouteTree` with the host.
+func newHostRouteTree(host string) *hostRouteTree {
+	hrt := &hostRouteTree{
+		host:   host,
+		labels: strings.Split(host, "."),
+		routeTree: &routeNode{
+			handlers: map[string]Handler{},
+			routes:   map[string]*Route{},
+		},
+	}
+
+	for i, l := range hrt.labels {
+		switch {
+		case l == "":
+			panic("air: route host cannot have empty labels")
+		case l[0] == '*':
+			if i > 0 {
+				panic("air: * can only appear at beginning " +
+					"of route host")
 			}
 
-			cn = nn
+			pn := l[1:]
+			if pn == "" {
+				pn = "*"
+			}
+
+			hrt.paramNames = append(hrt.paramNames, pn)
+		case l[0] == ':':
+			if len(l) == 1 {
+				panic("air: route host cannot have empty " +
+					"param names")
+			} else if stringSliceContains(hrt.paramNames, l[1:]) {
+				panic("air: route host cannot have duplicate " +
+					"param names")
+			}
+
+			hrt.paramNames = append(hrt.paramNames, l[1:])
+		}
+	}
+
+	return hrt
+}
 
-			for i, sl = 0, len(s); i < sl && s[i] != '/'; i++ {
+// notFoundHandler returns a `Handler` for the req that has no matching routes
+// in the route trees of the t that the host falls under. It redirects the req
+// if there is a matching route for its trailing slash toggled path or its fixed
+// path (depending on the redirect policies of the `r.a`), otherwise it is the
+// `NotFoundHandler` of the `r.a`.
+func (r *router) notFoundHandler(
+	req *Request,
+	t *routeTable,
+	host string,
+) Handler {
+	if !r.a.RouterRedirectTrailingSlash && !r.a.RouterRedirectFixedPath {
+		return r.a.notFoundHandler(req)
+	}
+
+	lookup := func(path string, ci bool) string {
+		for i := 0; ; {
+			tree, _, ni := r.nextRouteTree(req, t, host, i)
+			if tree == nil {
+				return ""
 			}
 
-			if req.routeParamValues == nil {
-				req.routeParamValues = r.routeParamValuesPool.
-					Get().([]string)
+			rp, _ := tree.lookup(req.Method, path, ci)
+			if rp != "" {
+				return rp
 			}
 
-			req.routeParamValues[pi] = s[:i]
-			pi++
+			i = ni
+		}
+	}
+
+	p, q := splitPathQuery(req.Path)
+
+	rp := "" // Redirect path
+	if r.a.RouterRedirectTrailingSlash {
+		rp = lookup(toggleTrailingSlash(p), false)
+	}
+
+	if rp == "" && r.a.RouterRedirectFixedPath {
+		cp := ppath.Clean(p)
+		if cp != "/" && strings.HasSuffix(p, "/") {
+			cp += "/"
+		}
+
+		rp = lookup(cp, true)
+		if rp == "" && r.a.RouterRedirectTrailingSlash {
+			rp = lookup(toggleTrailingSlash(cp), true)
+		}
+	}
+
+	if rp == "" || rp == p {
+		return r.a.notFoundHandler(req)
+	}
+
+	if q != "" {
+		rp += "?" + q
+	}
+
+	return func(req *Request, res *Response) error {
+		res.Status = http.StatusPermanentRedirect
+		if req.Method == http.MethodGet ||
+			req.Method == http.MethodHead {
+			res.Status = http.StatusMovedPermanently
+		}
+
+		return res.Redirect(rp)
+	}
+}
+
+// routesSnapshot returns a snapshot of all registered routes of the r in
+// the order of registration.
+func (r *router) routesSnapshot() []*Route {
+	r.Lock()
+	defer r.Unlock()
+
+	return append(make([]*Route, 0, len(r.routes)), r.routes...)
+}
+
+// url returns a URL path built from the first route named with the name in the
+// r by filling its params with the params. It returns "" if any of the required
+// params of the route is missing.
+func (r *router) url(name string, params map[string]interface{}) string {
+	var rt *Route
+	r.Lock()
+	for _, v := range r.routes {
+		if v.Name == name {
+			rt = v
+			break
+		}
+	}
+
+	r.Unlock()
+
+	if rt == nil {
+		return ""
+	}
+
+	paramValue := func(name string) string {
+		if v, ok := params[name]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+
+		return ""
+	}
+
+	b := []byte{}
+	for p := rt.Path; p != ""; {
+		switch p[0] {
+		case ':':
+			i := strings.IndexAny(p, "/<")
+			if i < 0 {
+				i = len(p)
+			}
+
+			pn := p[1:i]
+			pv := ""
+			if strings.HasSuffix(pn, "?") {
+				pv = paramValue(pn[:len(pn)-1])
+				if pv == "" && len(b) > 0 &&
+					b[len(b)-1] == '/' {
+					b = b[:len(b)-1]
+				}
+			} else if pv = paramValue(pn); pv == "" {
+				return ""
+			}
+
+			b = append(b, url.PathEscape(pv)...)
+			p = p[i:]
+			p = p[routeParamConstraintLength(p):]
+		case '*':
+			i := strings.IndexByte(p, '/')
+			if i < 0 {
+				i = len(p)
+			}
+
+			pn := p[1:i]
+			if pn == "" {
+				pn = "*"
+			}
+
+			pv := paramValue(pn)
+			if pv == "" && i < len(p) {
+				return ""
+			}
+
+			ss := strings.Split(pv, "/")
+			for i, s := range ss {
+				ss[i] = url.PathEscape(s)
+			}
+
+			b = append(b, strings.Join(ss, "/")...)
+			p = p[i:]
+		default:
+			i := strings.IndexAny(p, ":*")
+			if i < 0 {
+				i = len(p)
+			}
+
+			b = append(b, strings.Replace(
+				url.PathEscape(p[:i]),
+				"%2F",
+				"/",
+				-1,
+			)...)
+			p = p[i:]
+		}
+	}
+
+	if len(b) == 0 || b[0] != '/' {
+		b = append([]byte{'/'}, b...)
+	}
+
+	return string(b)
+}
+
+// Route is a route registered in the router of an `Air`.
+//
+// It is highly recommended not to modify the value of any field of the `Route`
+// except the `Name` and the `Metadata`, which will cause unpredictable
+// problems.
+type Route struct {
+	// Method is the method of the current route.
+	Method string
+
+	// Path is the path pattern of the current route, it is the same as
+	// the path used to register the current route (after cleaning).
+	Path string
+
+	// Host is the host pattern of the current route. It is empty if the
+	// current route is not limited to any host. See the `Group.Host`.
+	Host string
+
+	// ParamNames is the names of the params in the `Host` and the `Path` of
+	// the current route in order.
+	ParamNames []string
+
+	// GroupPrefix is the prefix of the `Group` that registered the current
+	// route. It is empty if the current route was not registered by a
+	// `Group`.
+	GroupPrefix string
+
+	// Name is the name of the current route.
+	//
+	// The `Name` is optional. Once it is set, the current route can be
+	// reversed into a URL path by calling the `Air.URL`.
+	Name string
+
+	// Metadata is the metadata of the current route.
+	//
+	// The `Metadata` is optional and never used by this framework. It is
+	// used to attach arbitrary information to the current route, which can
+	// be read back from the `Request.Route` when serving.
+	Metadata map[string]interface{}
+
+	// MaxRequestBodyBytes is the maximum number of bytes of the request
+	// bodies of the current route.
+	//
+	// If the `MaxRequestBodyBytes` is not zero, it will be used instead of
+	// the `Group.MaxRequestBodyBytes` and the `Air.MaxRequestBodyBytes`. A
+	// negative value means no limit.
+	MaxRequestBodyBytes int64
+
+	handler    Handler
+	gases      []Gas
+	keys       []string
+	insertions []routeInsertion
+}
+
+// routeInsertion is an insertion of a `Route` into a route tree.
+type routeInsertion struct {
+	path         string
+	nt           routeNodeType
+	paramNames   []string
+	paramRegexps []*regexp.Regexp
+	handled      bool
+}
+
+// routePathVariant is a variant of a route path.
+type routePathVariant struct {
+	path             string
+	paramConstraints []string
+}
+
+// routePathVariants returns the variants of the path by including and excluding
+// each of its optional params (the ones named with a "?" suffix). The pcs are
+// the param constraints of the path. The first variant always includes all the
+// optional params.
+func routePathVariants(path string, pcs []string) []routePathVariant {
+	type variant struct {
+		segments []string
+		pcs      []string
+	}
+
+	vs := []variant{{}}
+	for _, s := range strings.Split(path, "/") {
+		n := strings.Count(s, ":")
+		spcs := make([]string, n)
+		for i := range spcs {
+			if len(pcs) > 0 {
+				spcs[i], pcs = pcs[0], pcs[1:]
+			}
+		}
 
-			s = s[i:]
+		optional := n == 1 && s[0] == ':' && strings.HasSuffix(s, "?")
+		if optional {
+			s = s[:len(s)-1]
+		}
+
+		for i, l := 0, len(vs); i < l; i++ {
+			v := vs[i]
+			if optional {
+				vs = append(vs, v)
+			}
+
+			vs[i] = variant{
+				segments: make([]string, 0, len(v.segments)+1),
+				pcs:      make([]string, 0, len(v.pcs)+n),
+			}
+			vs[i].segments = append(vs[i].segments, v.segments...)
+			vs[i].segments = append(vs[i].segments, s)
+			vs[i].pcs = append(vs[i].pcs, v.pcs...)
+			vs[i].pcs = append(vs[i].pcs, spcs...)
+		}
+	}
+
+	rpvs := make([]routePathVariant, 0, len(vs))
+	for _, v := range vs {
+		p := strings.Join(v.segments, "/")
+		if p == "" {
+			p = "/"
+		}
+
+		rpvs = append(rpvs, routePathVariant{
+			path:             p,
+			paramConstraints: v.pcs,
+		})
+	}
+
+	return rpvs
+}
+
+// routeParamRegexp returns a `regexp.Regexp` for the route param constraint
+// pc. The pc is either a built-in name of the `routeParamPatterns` or a regular
+// expression that must match the whole route param value.
+func (r *router) routeParamRegexp(pc string) *regexp.Regexp {
+	if re, ok := r.routeParamRegexps[pc]; ok {
+		return re
+	}
+
+	p, ok := routeParamPatterns[pc]
+	if !ok {
+		p = pc
+	}
+
+	re, err := regexp.Compile("^(?:" + p + ")$")
+	if err != nil {
+		panic(fmt.Sprintf(
+			"air: invalid route param constraint: %s",
+			pc,
+		))
+	}
+
+	r.routeParamRegexps[pc] = re
+
+	return re
+}
+
+// routeParamPatterns is the patterns of the built-in route param constraints.
+var routeParamPatterns = map[string]string{
+	"int":   `[+-]?[0-9]+`,
+	"uint":  `\+?[0-9]+`,
+	"float": `[+-]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][+-]?[0-9]+)?`,
+	"alpha": `[A-Za-z]+`,
+	"alnum": `[0-9A-Za-z]+`,
+	"hex":   `[0-9A-Fa-f]+`,
+	"uuid": `[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-` +
+		`[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}`,
+}
 
+// splitRouteParamConstraints splits the route param constraints (of the form
+// ":name<constraint>") out of the path. It returns the path without the
+// constraints and the constraints of all route params in order ("" means no
+// constraint).
+func splitRouteParamConstraints(path string) (string, []string) {
+	if !strings.Contains(path, ":") {
+		return path, nil
+	}
+
+	b := strings.Builder{}
+	pcs := []string{}
+	for i, l := 0, len(path); i < l; i++ {
+		b.WriteByte(path[i])
+		if path[i] != ':' {
 			continue
 		}
 
-		// Any node.
-	Any:
-		if cn = cn.childByType(routeNodeTypeAny); cn != nil {
-			if req.routeParamValues == nil {
-				req.routeParamValues = r.routeParamValuesPool.
-					Get().([]string)
+		for i++; i < l && path[i] != '/' && path[i] != '<'; i++ {
+			b.WriteByte(path[i])
+		}
+
+		pc := ""
+		if i < l && path[i] == '<' {
+			j := i + routeParamConstraintLength(path[i:])
+			if j == i {
+				panic("air: unclosed route param constraint")
 			}
 
-			req.routeParamValues[len(cn.paramNames)-1] = s
+			pc, i = path[i+1:j-1], j
+		}
 
-			break
+		pcs = append(pcs, pc)
+		i--
+	}
+
+	return b.String(), pcs
+}
+
+// joinRouteParamConstraints is the opposite of the
+// `splitRouteParamConstraints`.
+func joinRouteParamConstraints(path string, pcs []string) string {
+	if len(pcs) == 0 {
+		return path
+	}
+
+	b := strings.Builder{}
+	for i, l, pi := 0, len(path), 0; i < l; i++ {
+		b.WriteByte(path[i])
+		if path[i] != ':' {
+			continue
+		}
+
+		for i++; i < l && path[i] != '/'; i++ {
+			b.WriteByte(path[i])
 		}
 
-		// Struggle for the former node.
-	Struggle:
-		if sn != nil {
-			cn = sn
-			sn = nil
-			s = ss
+		if pi < len(pcs) && pcs[pi] != "" {
+			b.WriteString("<" + pcs[pi] + ">")
+		}
+
+		pi++
+		i--
+	}
+
+	return b.String()
+}
+
+// toggleTrailingSlash returns the p with its trailing slash toggled.
+func toggleTrailingSlash(p string) string {
+	if p == "/" {
+		return p
+	} else if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+
+	return p + "/"
+}
+
+// routeParamConstraintLength returns the length of the route param constraint
+// at the beginning of the s (including the angle brackets). It returns zero if
+// the s does not start with a well-formed constraint.
+func routeParamConstraintLength(s string) int {
+	if s == "" || s[0] != '<' {
+		return 0
+	}
 
-			switch nnt {
-			case routeNodeTypeParam:
-				goto Param
-			case routeNodeTypeAny:
-				goto Any
+	d := 0 // Depth
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '<':
+			d++
+		case '>':
+			if d--; d == 0 {
+				return i + 1
 			}
 		}
+	}
+
+	return 0
+}
+
+// hostRouteTree is a route tree for a host pattern.
+type hostRouteTree struct {
+	host       string
+	labels     []string
+	paramNames []string
+	routeTree  *routeNode
+}
 
-		return r.a.NotFoundHandler
+// rank returns the rank of the hrt. The lower the rank, the higher the
+// priority when matching hosts.
+func (hrt *hostRouteTree) rank() int {
+	if hrt.labels[0][0] == '*' {
+		return 2
+	} else if len(hrt.paramNames) > 0 {
+		return 1
 	}
 
-	h := cn.handlers[req.Method]
-	if h != nil {
-		req.routeParamNames = cn.paramNames
-	} else if len(cn.handlers) != 0 {
-		h = r.a.MethodNotAllowedHandler
-	} else {
-		h = r.a.NotFoundHandler
+	return 0
+}
+
+// match reports whether the host matches the hrt. The values of the host params
+// will be filled into the pvs in order if the pvs is not nil. The labels
+// matched by the leading "*" label are captured as a whole.
+func (hrt *hostRouteTree) match(host string, pvs []string) bool {
+	ls := hrt.labels
+	if ls[0][0] == '*' {
+		ls = ls[1:]
 	}
 
-	return h
+	pi, e := len(hrt.paramNames), len(host) // Param index, end
+	for i := len(ls) - 1; i >= 0; i-- {
+		if e <= 0 {
+			return false
+		}
+
+		b := strings.LastIndexByte(host[:e], '.') + 1
+		if l := host[b:e]; l == "" {
+			return false
+		} else if ls[i][0] == ':' {
+			if pi--; pvs != nil {
+				pvs[pi] = l
+			}
+		} else if l != ls[i] {
+			return false
+		}
+
+		e = b - 1
+	}
+
+	if len(ls) < len(hrt.labels) {
+		if e <= 0 {
+			return false
+		}
+
+		if pvs != nil {
+			pvs[0] = host[:e]
+		}
+
+		return true
+	}
+
+	return e < 0
 }
 
 // routeNode is the node of the route radix tree.
 type routeNode struct {
-	label      byte
-	nType      routeNodeType
-	prefix     string
-	children   []*routeNode
-	paramNames []string
-	handlers   map[string]Handler
+	label          byte
+	nType          routeNodeType
+	prefix         string
+	staticIndices  string
+	staticChildren []*routeNode
+	paramChildren  []*routeNode
+	anyChild       *routeNode
+	paramNames     []string
+	paramRegexp    *regexp.Regexp
+	handlers       map[string]Handler
+	routes         map[string]*Route
+	pattern        string
 }
 
-// child returns a child node of the rn by the l and the t.
-func (rn *routeNode) child(l byte, t routeNodeType) *routeNode {
-	for _, c := range rn.children {
-		if c.label == l && c.nType == t {
-			return c
+// setHandler sets the h of the rt for the method in the rn.
+func (rn *routeNode) setHandler(method string, h Handler, rt *Route) {
+	rn.handlers[method] = h
+	rn.routes[method] = rt
+	if rn.pattern == "" {
+		rn.pattern = rt.Path
+	}
+}
+
+// addChild adds the c into the children of the rn. Static child nodes are
+// indexed by their labels, and param child nodes with regexps always stay in
+// front of the one without.
+func (rn *routeNode) addChild(c *routeNode) {
+	switch c.nType {
+	case routeNodeTypeStatic:
+		rn.staticIndices += string(c.label)
+		rn.staticChildren = append(rn.staticChildren, c)
+	case routeNodeTypeParam:
+		if c.paramRegexp != nil {
+			for i, v := range rn.paramChildren {
+				if v.paramRegexp == nil {
+					rn.paramChildren = append(
+						rn.paramChildren,
+						nil,
+					)
+					copy(
+						rn.paramChildren[i+1:],
+						rn.paramChildren[i:],
+					)
+					rn.paramChildren[i] = c
+					return
+				}
+			}
 		}
+
+		rn.paramChildren = append(rn.paramChildren, c)
+	case routeNodeTypeAny:
+		rn.anyChild = c
+	}
+}
+
+// children returns all child nodes of the rn in the order of priority.
+func (rn *routeNode) children() []*routeNode {
+	cs := make(
+		[]*routeNode,
+		0,
+		len(rn.staticChildren)+len(rn.paramChildren)+1,
+	)
+	cs = append(cs, rn.staticChildren...)
+	cs = append(cs, rn.paramChildren...)
+	if rn.anyChild != nil {
+		cs = append(cs, rn.anyChild)
+	}
+
+	return cs
+}
+
+// staticChild returns a static child node of the rn by the l.
+func (rn *routeNode) staticChild(l byte) *routeNode {
+	if i := strings.IndexByte(rn.staticIndices, l); i >= 0 {
+		return rn.staticChildren[i]
 	}
 
 	return nil
@@ -464,19 +1615,193 @@ func (rn *routeNode) child(l byte, t routeNodeType) *routeNode {
 
 // childByLabel returns a child node of the rn by the l.
 func (rn *routeNode) childByLabel(l byte) *routeNode {
-	for _, c := range rn.children {
-		if c.label == l {
-			return c
+	switch l {
+	case ':':
+		if len(rn.paramChildren) > 0 {
+			return rn.paramChildren[0]
 		}
+
+		return nil
+	case '*':
+		return rn.anyChild
 	}
 
-	return nil
+	return rn.staticChild(l)
+}
+
+// handles reports whether the rn has a handler for the method. An empty method
+// means any method. The "HEAD" is also handled by the handler for the "GET".
+func (rn *routeNode) handles(method string) bool {
+	if method == "" {
+		return len(rn.handlers) > 0
+	}
+
+	return rn.handlers[method] != nil ||
+		method == http.MethodHead && rn.handlers[http.MethodGet] != nil
+}
+
+// collectMethods collects the methods of the handlers of all nodes in the rn
+// and its descendants that match the s into the ms.
+func (rn *routeNode) collectMethods(s string, ms map[string]bool) {
+	switch rn.nType {
+	case routeNodeTypeStatic:
+		for len(s) > 1 && s[0] == '/' && s[1] == '/' {
+			s = s[1:]
+		}
+
+		if !strings.HasPrefix(s, rn.prefix) {
+			return
+		}
+
+		s = s[len(rn.prefix):]
+	case routeNodeTypeParam:
+		i := strings.IndexByte(s, '/')
+		if i < 0 {
+			i = len(s)
+		}
+
+		if !rn.acceptsParamValue(s[:i]) {
+			return
+		}
+
+		s = s[i:]
+	case routeNodeTypeAny:
+		for i := 1; i < len(s); i++ {
+			if s[i] == '/' {
+				for _, c := range rn.children() {
+					c.collectMethods(s[i:], ms)
+				}
+			}
+		}
+
+		for m := range rn.handlers {
+			ms[m] = true
+		}
+
+		return
+	}
+
+	if s == "" {
+		for m := range rn.handlers {
+			ms[m] = true
+		}
+	} else if c := rn.staticChild(s[0]); c != nil {
+		c.collectMethods(s, ms)
+	}
+
+	for _, c := range rn.paramChildren {
+		c.collectMethods(s, ms)
+	}
+
+	if rn.anyChild != nil {
+		rn.anyChild.collectMethods(s, ms)
+	}
+}
+
+// allowedMethods returns the value of the Allow header for the methods ms. The
+// "OPTIONS" is always allowed since it can be answered automatically, so is the
+// "HEAD" when the "GET" is allowed.
+func allowedMethods(ms map[string]bool) string {
+	ams := make([]string, 0, len(ms)+2)
+	for m := range ms {
+		ams = append(ams, m)
+	}
+
+	if ms[http.MethodGet] && !ms[http.MethodHead] {
+		ams = append(ams, http.MethodHead)
+	}
+
+	if !ms[http.MethodOptions] {
+		ams = append(ams, http.MethodOptions)
+	}
+
+	sort.Strings(ams)
+
+	return strings.Join(ams, ", ")
+}
+
+// lookup looks up the rn and its descendants for a route that matches the s
+// and has a handler for the method, and returns the registered form of the s
+// if found. The ci indicates whether the static parts of the s are compared
+// case-insensitively.
+//
+// Search order: static route > param route > any route.
+func (rn *routeNode) lookup(method, s string, ci bool) (string, bool) {
+	var p string // Path
+	switch rn.nType {
+	case routeNodeTypeStatic:
+		pl := len(rn.prefix)
+		if len(s) < pl {
+			return "", false
+		} else if ci && !strings.EqualFold(s[:pl], rn.prefix) {
+			return "", false
+		} else if !ci && s[:pl] != rn.prefix {
+			return "", false
+		}
+
+		p, s = rn.prefix, s[pl:]
+	case routeNodeTypeParam:
+		i := strings.IndexByte(s, '/')
+		if i < 0 {
+			i = len(s)
+		}
+
+		if rn.paramRegexp != nil {
+			v, err := url.PathUnescape(s[:i])
+			if err != nil || !rn.paramRegexp.MatchString(v) {
+				return "", false
+			}
+		}
+
+		p, s = s[:i], s[i:]
+	case routeNodeTypeAny:
+		for i := 1; i < len(s); i++ {
+			if s[i] != '/' {
+				continue
+			}
+
+			for _, c := range rn.children() {
+				if cp, ok := c.lookup(method, s[i:], ci); ok {
+					return s[:i] + cp, true
+				}
+			}
+		}
+
+		p, s = s, ""
+	}
+
+	if s == "" && (rn.handlers[method] != nil ||
+		method == http.MethodHead &&
+			rn.handlers[http.MethodGet] != nil) {
+		return p, true
+	}
+
+	for _, c := range rn.children() {
+		if cp, ok := c.lookup(method, s, ci); ok {
+			return p + cp, true
+		}
+	}
+
+	return "", false
+}
+
+// acceptsParamValue reports whether the rn accepts the route param value v.
+func (rn *routeNode) acceptsParamValue(v string) bool {
+	if rn.paramRegexp == nil {
+		return true
+	}
+
+	if uv, err := url.PathUnescape(v); err == nil {
+		v = uv
+	}
+
+	return rn.paramRegexp.MatchString(v)
 }
 
-// childByType returns a child node of the rn by the t.
-func (rn *routeNode) childByType(t routeNodeType) *routeNode {
-	for _, c := range rn.children {
-		if c.nType == t {
+// paramChildByRegexp returns a param child node of the rn by the re.
+func (rn *routeNode) paramChildByRegexp(re *regexp.Regexp) *routeNode {
+	for _, c := range rn.paramChildren {
+		if c.paramRegexp == re {
 			return c
 		}
 	}
@@ -1,7 +1,12 @@
 package air
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,8 +18,8 @@ func TestNewRouter(t *testing.T) {
 
 	assert.NotNil(t, r)
 	assert.NotNil(t, r.a)
-	assert.NotNil(t, r.routeTree)
-	assert.NotNil(t, r.routeTree.handlers)
+	assert.NotNil(t, r.loadRouteTable().routeTree)
+	assert.NotNil(t, r.loadRouteTable().routeTree.handlers)
 	assert.NotNil(t, r.registeredRoutes)
 	assert.NotNil(t, r.routeParamValuesPool)
 }
@@ -64,7 +69,8 @@ func TestRouterRegister(t *testing.T) {
 
 	assert.PanicsWithValue(
 		t,
-		"air: only one * is allowed in route path",
+		"air: * must be at beginning of segment unless in last "+
+			"segment of route path",
 		func() {
 			r.register(m, "/foo*/bar*", h)
 		},
@@ -72,12 +78,30 @@ func TestRouterRegister(t *testing.T) {
 
 	assert.PanicsWithValue(
 		t,
-		"air: * can only appear at end of route path",
+		"air: * must be at beginning of segment unless in last "+
+			"segment of route path",
 		func() {
 			r.register(m, "/foo*/bar", h)
 		},
 	)
 
+	assert.PanicsWithValue(
+		t,
+		"air: adjacent * in route path must be separated by /",
+		func() {
+			r.register(m, "/*foo*bar", h)
+		},
+	)
+
+	assert.PanicsWithValue(
+		t,
+		"air: adjacent param name and * in route path must be "+
+			"separated by /",
+		func() {
+			r.register(m, "/*foo:bar/baz", h)
+		},
+	)
+
 	assert.PanicsWithValue(
 		t,
 		"air: adjacent param name and * in route path must be "+
@@ -142,8 +166,107 @@ func TestRouterRouteStatic(t *testing.T) {
 	assert.Empty(t, rec.Body.String())
 
 	req, res, rec = fakeRRCycle(a, http.MethodHead, "/", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, "15", rec.Header().Get("Content-Length"))
+	assert.Empty(t, rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodPost, "/", nil)
+	assert.Error(t, r.route(req)(req, res), "Method Not Allowed")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	assert.Equal(t, "GET, HEAD, OPTIONS", res.Header.Get("Allow"))
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestRouterRouteAllowedMethods(t *testing.T) {
+	a := New()
+	r := a.router
+
+	r.register(
+		http.MethodGet,
+		"/foo",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foo]")
+		},
+	)
+
+	r.register(
+		http.MethodPost,
+		"/foo",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [POST /foo]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /bar]")
+		},
+	)
+
+	r.register(
+		http.MethodOptions,
+		"/bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [OPTIONS /bar]")
+		},
+	)
+
+	req, res, rec := fakeRRCycle(a, http.MethodDelete, "/foo", nil)
 	assert.Error(t, r.route(req)(req, res), "Method Not Allowed")
 	assert.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header.Get("Allow"))
+	assert.Empty(t, rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodOptions, "/foo", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header.Get("Allow"))
+	assert.Empty(t, rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodOptions, "/bar", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Empty(t, res.Header.Get("Allow"))
+	assert.Equal(t, "Matched [OPTIONS /bar]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodDelete, "/bar", nil)
+	assert.Error(t, r.route(req)(req, res), "Method Not Allowed")
+	assert.Equal(t, "GET, HEAD, OPTIONS", res.Header.Get("Allow"))
+
+	req, res, rec = fakeRRCycle(a, http.MethodOptions, "/foobar", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, res.Header.Get("Allow"))
+
+	r.register(
+		http.MethodHead,
+		"/bar",
+		func(_ *Request, res *Response) error {
+			res.Header.Set("Foo", "bar")
+			return res.Write(nil)
+		},
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodHead, "/bar", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "bar", rec.Header().Get("Foo"))
+	assert.Empty(t, rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodHead, "/foo", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Empty(t, rec.Header().Get("Foo"))
+	assert.Empty(t, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, httptest.NewRequest(
+		http.MethodOptions,
+		"/foo",
+		nil,
+	))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", rec.Header().Get("Allow"))
 	assert.Empty(t, rec.Body.String())
 }
 
@@ -213,6 +336,172 @@ func TestRouterRouteParam(t *testing.T) {
 	assert.Equal(t, "Matched [GET /foo:bar]", rec.Body.String())
 }
 
+func TestRouterRouteParamConstraint(t *testing.T) {
+	a := New()
+	r := a.router
+
+	assert.PanicsWithValue(
+		t,
+		"air: unclosed route param constraint",
+		func() {
+			r.register(http.MethodGet, "/:foo<int", func(
+				_ *Request,
+				_ *Response,
+			) error {
+				return nil
+			})
+		},
+	)
+
+	assert.PanicsWithValue(
+		t,
+		"air: invalid route param constraint: [a-z",
+		func() {
+			r.register(http.MethodGet, "/:foo<[a-z>", func(
+				_ *Request,
+				_ *Response,
+			) error {
+				return nil
+			})
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/files/:name",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /files/:name]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/files/:id<int>",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /files/:id<int>]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/files/:uuid<uuid>/meta",
+		func(_ *Request, res *Response) error {
+			return res.WriteString(
+				"Matched [GET /files/:uuid<uuid>/meta]",
+			)
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/posts/:slug<[a-z0-9-]+>",
+		func(_ *Request, res *Response) error {
+			return res.WriteString(
+				"Matched [GET /posts/:slug<[a-z0-9-]+>]",
+			)
+		},
+	)
+
+	assert.PanicsWithValue(
+		t,
+		"air: route already exists",
+		func() {
+			r.register(http.MethodGet, "/files/:no<int>", func(
+				_ *Request,
+				_ *Response,
+			) error {
+				return nil
+			})
+		},
+	)
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/files/123", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.NotNil(t, req.Param("id"))
+	assert.Equal(t, "123", req.Param("id").Value().String())
+	assert.Nil(t, req.Param("name"))
+	assert.Equal(t, "Matched [GET /files/:id<int>]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/files/foo", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.NotNil(t, req.Param("name"))
+	assert.Equal(t, "foo", req.Param("name").Value().String())
+	assert.Nil(t, req.Param("id"))
+	assert.Equal(t, "Matched [GET /files/:name]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(
+		a,
+		http.MethodGet,
+		"/files/0f8fad5b-d9cb-469f-a165-70867728950e/meta",
+		nil,
+	)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.NotNil(t, req.Param("uuid"))
+	assert.Equal(
+		t,
+		"Matched [GET /files/:uuid<uuid>/meta]",
+		rec.Body.String(),
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/files/foo/meta", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/posts/foo-bar-1", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "foo-bar-1", req.Param("slug").Value().String())
+	assert.Equal(
+		t,
+		"Matched [GET /posts/:slug<[a-z0-9-]+>]",
+		rec.Body.String(),
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/posts/Foobar", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, rec.Body.String())
+
+	r.register(
+		http.MethodGet,
+		"/g/:id<int>",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /g/:id<int>]")
+		},
+	)
+
+	r.register(
+		http.MethodPost,
+		"/g/:name",
+		func(req *Request, res *Response) error {
+			return res.WriteString("Matched [POST /g/:name]")
+		},
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodPost, "/g/12", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "12", req.Param("name").Value().String())
+	assert.Nil(t, req.Param("id"))
+	assert.Equal(t, "Matched [POST /g/:name]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/g/12", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "Matched [GET /g/:id<int>]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodPut, "/g/12", nil)
+	assert.Error(t, r.route(req)(req, res), "Method Not Allowed")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	assert.Equal(
+		t,
+		"GET, HEAD, OPTIONS, POST",
+		rec.Header().Get("Allow"),
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodPut, "/g/foo", nil)
+	assert.Error(t, r.route(req)(req, res), "Method Not Allowed")
+	assert.Equal(t, "OPTIONS, POST", rec.Header().Get("Allow"))
+}
+
 func TestRouterRouteAny(t *testing.T) {
 	a := New()
 	r := a.router
@@ -338,71 +627,330 @@ func TestRouterRouteAny(t *testing.T) {
 	assert.Equal(t, "Matched [GET /foobar*]", rec.Body.String())
 }
 
-func TestRouterRouteMix(t *testing.T) {
+func TestRouterRouteRedirect(t *testing.T) {
 	a := New()
 	r := a.router
 
 	r.register(
 		http.MethodGet,
-		"/",
+		"/about",
 		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /]")
+			return res.WriteString("Matched [GET /about]")
 		},
 	)
 
 	r.register(
-		http.MethodGet,
-		"/foo",
+		http.MethodPost,
+		"/users/:id<int>/Posts",
 		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /foo]")
+			return res.WriteString(
+				"Matched [POST /users/:id<int>/Posts]",
+			)
 		},
 	)
 
+	req, res, _ := fakeRRCycle(a, http.MethodGet, "/about/", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+
+	a.RouterRedirectTrailingSlash = true
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/about/?foo=bar", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusMovedPermanently, res.Status)
+	assert.Equal(t, "/about?foo=bar", rec.Header().Get("Location"))
+
+	req, res, rec = fakeRRCycle(a, http.MethodPost, "/users/1/Posts/", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusPermanentRedirect, res.Status)
+	assert.Equal(t, "/users/1/Posts", rec.Header().Get("Location"))
+
+	req, res, _ = fakeRRCycle(a, http.MethodPost, "/users/foo/Posts/", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+
+	req, res, _ = fakeRRCycle(a, http.MethodGet, "/About", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+
+	a.RouterRedirectTrailingSlash = false
+	a.RouterRedirectFixedPath = true
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/ABOUT", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusMovedPermanently, res.Status)
+	assert.Equal(t, "/about", rec.Header().Get("Location"))
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo/../About", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusMovedPermanently, res.Status)
+	assert.Equal(t, "/about", rec.Header().Get("Location"))
+
+	req, res, _ = fakeRRCycle(a, http.MethodGet, "/About/", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+
+	a.RouterRedirectTrailingSlash = true
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/About/", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusMovedPermanently, res.Status)
+	assert.Equal(t, "/about", rec.Header().Get("Location"))
+
+	req, res, rec = fakeRRCycle(a, http.MethodPost, "/USERS/1/posts/", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, http.StatusPermanentRedirect, res.Status)
+	assert.Equal(t, "/users/1/Posts", rec.Header().Get("Location"))
+
+	req, res, _ = fakeRRCycle(a, http.MethodGet, "/USERS/1/posts", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+}
+
+func TestRouterRouteHost(t *testing.T) {
+	a := New()
+	r := a.router
+
+	for _, host := range []string{
+		"foo..com",
+		"foo.*.com",
+		"foo.:.com",
+		":foo.:foo.com",
+		"*foo.:foo.com",
+	} {
+		assert.Panics(t, func() {
+			r.registerHost(host, http.MethodGet, "/", func(
+				_ *Request,
+				_ *Response,
+			) error {
+				return nil
+			})
+		})
+	}
+
 	r.register(
 		http.MethodGet,
-		"/bar",
+		"/",
 		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /bar]")
+			return res.WriteString("Matched [GET /]")
 		},
 	)
 
-	r.register(
+	r.registerHost(
+		"api.example.com",
 		http.MethodGet,
-		"/foobar",
+		"/",
 		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /foobar]")
+			return res.WriteString("Matched [GET api.example.com/]")
 		},
 	)
 
-	r.register(
+	r.registerHost(
+		":tenant.example.com",
 		http.MethodGet,
-		"/:foobar",
+		"/users/:id",
 		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /:foobar]")
+			return res.WriteString(
+				"Matched [GET :tenant.example.com/users/:id]",
+			)
 		},
 	)
 
-	r.register(
+	rt := r.registerHost(
+		"*.example.org",
 		http.MethodGet,
-		"/foo/:bar",
+		"/",
 		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /foo/:bar]")
+			return res.WriteString("Matched [GET *.example.org/]")
 		},
 	)
+	assert.Equal(t, "*.example.org", rt.Host)
+
+	assert.PanicsWithValue(t, "air: route already exists", func() {
+		r.registerHost("api.example.com", http.MethodGet, "/", func(
+			_ *Request,
+			_ *Response,
+		) error {
+			return nil
+		})
+	})
 
-	r.register(
-		http.MethodGet,
-		"/foo:bar",
-		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /foo:bar]")
-		},
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "Matched [GET /]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Authority = "API.example.com:8080"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "Matched [GET api.example.com/]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/users/1", nil)
+	req.Authority = "foo.example.com"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "foo", req.Param("tenant").Value().String())
+	assert.Equal(t, "1", req.Param("id").Value().String())
+	assert.Equal(
+		t,
+		"Matched [GET :tenant.example.com/users/:id]",
+		rec.Body.String(),
 	)
 
-	r.register(
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Authority = "foo.example.com"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Nil(t, req.Param("tenant"))
+	assert.Equal(t, "Matched [GET /]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo", nil)
+	req.Authority = "foo.example.com"
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Authority = "foo.bar.example.org"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "foo.bar", req.Param("*").Value().String())
+	assert.Equal(t, "Matched [GET *.example.org/]", rec.Body.String())
+
+	r.registerHost(
+		"*sub.:tenant.example.net",
 		http.MethodGet,
-		"/:foo/:bar",
+		"/*",
 		func(_ *Request, res *Response) error {
-			return res.WriteString("Matched [GET /:foo/:bar]")
+			return res.WriteString(
+				"Matched [GET *sub.:tenant.example.net/*]",
+			)
+		},
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo/bar", nil)
+	req.Authority = "a.b.foo.example.net"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "a.b", req.Param("sub").Value().String())
+	assert.Equal(t, "foo", req.Param("tenant").Value().String())
+	assert.Equal(t, "foo/bar", req.Param("*").Value().String())
+	assert.Equal(
+		t,
+		"Matched [GET *sub.:tenant.example.net/*]",
+		rec.Body.String(),
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Authority = "example.org"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "Matched [GET /]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/users/1", nil)
+	req.Authority = "foo.bar.example.com"
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+
+	r.registerHost(
+		"*sub.example.com",
+		http.MethodGet,
+		"/",
+		func(_ *Request, res *Response) error {
+			return res.WriteString(
+				"Matched [GET *sub.example.com/]",
+			)
+		},
+	)
+
+	r.registerHost(
+		":t.example.com",
+		http.MethodGet,
+		"/t",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET :t.example.com/t]")
+		},
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/", nil)
+	req.Authority = "x.example.com"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "x", req.Param("sub").Value().String())
+	assert.Nil(t, req.Param("t"))
+	assert.Equal(t, "Matched [GET *sub.example.com/]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/t", nil)
+	req.Authority = "x.example.com"
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "x", req.Param("t").Value().String())
+	assert.Equal(t, "Matched [GET :t.example.com/t]", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodPost, "/t", nil)
+	req.Authority = "x.example.com"
+	assert.Error(t, r.route(req)(req, res), "Method Not Allowed")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	assert.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get("Allow"))
+}
+
+func TestRouterRouteMix(t *testing.T) {
+	a := New()
+	r := a.router
+
+	r.register(
+		http.MethodGet,
+		"/",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/foo",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foo]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /bar]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/foobar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foobar]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/:foobar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /:foobar]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/foo/:bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foo/:bar]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/foo:bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foo:bar]")
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/:foo/:bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /:foo/:bar]")
 		},
 	)
 
@@ -556,19 +1104,703 @@ func TestRouterRouteMix(t *testing.T) {
 	assert.Equal(t, "Matched [GET /:foo/:bar/*]", rec.Body.String())
 }
 
+func TestRouterRouteOptionalParam(t *testing.T) {
+	a := New()
+	r := a.router
+
+	rt := r.register(
+		http.MethodGet,
+		"/:lang?<[a-z]{2}>/docs/:page?",
+		func(req *Request, res *Response) error {
+			lang, page := "", ""
+			if p := req.Param("lang"); p != nil {
+				lang = p.Value().String()
+			}
+
+			if p := req.Param("page"); p != nil {
+				page = p.Value().String()
+			}
+
+			return res.WriteString(lang + ":" + page)
+		},
+	)
+	assert.Equal(t, "/:lang?<[a-z]{2}>/docs/:page?", rt.Path)
+	assert.Equal(t, []string{"lang", "page"}, rt.ParamNames)
+
+	assert.PanicsWithValue(t, "air: route already exists", func() {
+		r.register(http.MethodGet, "/docs", func(
+			_ *Request,
+			_ *Response,
+		) error {
+			return nil
+		})
+	})
+
+	assert.PanicsWithValue(t, "air: route already exists", func() {
+		r.register(http.MethodGet, "/:foo?/:bar?", func(
+			_ *Request,
+			_ *Response,
+		) error {
+			return nil
+		})
+	})
+
+	for path, body := range map[string]string{
+		"/en/docs/intro": "en:intro",
+		"/en/docs":       "en:",
+		"/docs/intro":    ":intro",
+		"/docs":          ":",
+	} {
+		req, res, rec := fakeRRCycle(a, http.MethodGet, path, nil)
+		assert.NoError(t, r.route(req)(req, res))
+		assert.Equal(t, body, rec.Body.String())
+	}
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/eng/docs", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, rec.Body.String())
+
+	r.remove(rt)
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/docs", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestRouterRouteNamedAny(t *testing.T) {
+	a := New()
+	r := a.router
+
+	assert.PanicsWithValue(
+		t,
+		"air: route path cannot have duplicate param names",
+		func() {
+			r.register(http.MethodGet, "/:foo/*foo", func(
+				_ *Request,
+				_ *Response,
+			) error {
+				return nil
+			})
+		},
+	)
+
+	rt := r.register(
+		http.MethodGet,
+		"/files/:bucket/*path",
+		func(req *Request, res *Response) error {
+			return res.WriteString(
+				req.Param("bucket").Value().String() + ":" +
+					req.Param("path").Value().String(),
+			)
+		},
+	)
+	assert.Equal(t, []string{"bucket", "path"}, rt.ParamNames)
+
+	assert.PanicsWithValue(t, "air: route already exists", func() {
+		r.register(http.MethodGet, "/files/:foo/*", func(
+			_ *Request,
+			_ *Response,
+		) error {
+			return nil
+		})
+	})
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/files/foo/a/b", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Nil(t, req.Param("*"))
+	assert.Equal(t, "foo:a/b", rec.Body.String())
+}
+
+func TestRouterRouteMultipleAny(t *testing.T) {
+	a := New()
+	r := a.router
+
+	assert.PanicsWithValue(
+		t,
+		"air: route path cannot have duplicate param names",
+		func() {
+			r.register(http.MethodGet, "/*/foo/*", func(
+				_ *Request,
+				_ *Response,
+			) error {
+				return nil
+			})
+		},
+	)
+
+	rt := r.register(
+		http.MethodGet,
+		"/*repo/-/blob/:ref/*path",
+		func(req *Request, res *Response) error {
+			return res.WriteString(strings.Join([]string{
+				req.Param("repo").Value().String(),
+				req.Param("ref").Value().String(),
+				req.Param("path").Value().String(),
+			}, " "))
+		},
+	)
+	assert.Equal(t, []string{"repo", "ref", "path"}, rt.ParamNames)
+
+	r.register(
+		http.MethodGet,
+		"/*repo/-/tree",
+		func(req *Request, res *Response) error {
+			return res.WriteString(
+				"tree " + req.Param("repo").Value().String(),
+			)
+		},
+	)
+
+	r.register(
+		http.MethodGet,
+		"/*",
+		func(req *Request, res *Response) error {
+			return res.WriteString(
+				"any " + req.Param("*").Value().String(),
+			)
+		},
+	)
+
+	assert.PanicsWithValue(t, "air: route already exists", func() {
+		r.register(http.MethodGet, "/*foo/-/tree", func(
+			_ *Request,
+			_ *Response,
+		) error {
+			return nil
+		})
+	})
+
+	req, res, rec := fakeRRCycle(
+		a,
+		http.MethodGet,
+		"/foo/bar/-/blob/main/a/b.go",
+		nil,
+	)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "foo/bar main a/b.go", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo/-/tree", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "tree foo", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/-/tree", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "any -/tree", rec.Body.String())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo/-/blob", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "any foo/-/blob", rec.Body.String())
+
+	rt.Name = "blob"
+	assert.Equal(t, "/foo/bar/-/blob/main/a/b.go", r.url(
+		"blob",
+		map[string]interface{}{
+			"repo": "foo/bar",
+			"ref":  "main",
+			"path": "a/b.go",
+		},
+	))
+	assert.Empty(t, r.url("blob", map[string]interface{}{
+		"ref":  "main",
+		"path": "a/b.go",
+	}))
+}
+
+func TestRouterRouteMatchedRoute(t *testing.T) {
+	a := New()
+	r := a.router
+
+	rt := r.register(
+		http.MethodGet,
+		"/users/:id",
+		func(req *Request, res *Response) error {
+			scope := req.Route().Metadata["scope"].(string)
+			return res.WriteString(scope)
+		},
+	)
+	rt.Name = "user"
+	rt.Metadata = map[string]interface{}{
+		"scope": "users:read",
+	}
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/users/1", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, rt, req.Route())
+	assert.Equal(t, "user", req.Route().Name)
+	assert.Equal(t, "/users/:id", req.RoutePattern())
+	assert.Equal(t, "users:read", rec.Body.String())
+
+	req, res, _ = fakeRRCycle(a, http.MethodHead, "/users/1", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, rt, req.Route())
+
+	req, res, _ = fakeRRCycle(a, http.MethodPost, "/users/1", nil)
+	assert.Error(t, r.route(req)(req, res), "Method Not Allowed")
+	assert.Nil(t, req.Route())
+	assert.Equal(t, "/users/:id", req.RoutePattern())
+
+	req, res, _ = fakeRRCycle(a, http.MethodGet, "/posts/1", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Nil(t, req.Route())
+	assert.Empty(t, req.RoutePattern())
+
+	r.register(
+		http.MethodGet,
+		"/:lang?<alpha>/docs",
+		func(_ *Request, _ *Response) error {
+			return nil
+		},
+	)
+
+	req, res, _ = fakeRRCycle(a, http.MethodGet, "/docs", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "/:lang?<alpha>/docs", req.RoutePattern())
+
+	a.Gases = []Gas{
+		func(next Handler) Handler {
+			return func(req *Request, res *Response) error {
+				assert.Equal(t, rt, req.Route())
+				return next(req, res)
+			}
+		},
+	}
+
+	rec = httptest.NewRecorder()
+	a.server.ServeHTTP(rec, httptest.NewRequest(
+		http.MethodGet,
+		"/users/1",
+		nil,
+	))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "users:read", rec.Body.String())
+}
+
+func TestRouterRouteBacktracking(t *testing.T) {
+	a := New()
+	r := a.router
+
+	for _, path := range []string{
+		"/a/b/c/x",
+		"/a/:p/c/y",
+		"/a/b/:q/z",
+		"/:r/b/c/w",
+		"/:r/b/*",
+	} {
+		path := path
+		r.register(
+			http.MethodGet,
+			path,
+			func(_ *Request, res *Response) error {
+				return res.WriteString(
+					"Matched [GET " + path + "]",
+				)
+			},
+		)
+	}
+
+	for path, body := range map[string]string{
+		"/a/b/c/x": "Matched [GET /a/b/c/x]",
+		"/a/b/c/y": "Matched [GET /a/:p/c/y]",
+		"/a/b/c/z": "Matched [GET /a/b/:q/z]",
+		"/a/b/c/w": "Matched [GET /:r/b/c/w]",
+		"/a/b/c/v": "Matched [GET /:r/b/*]",
+	} {
+		req, res, rec := fakeRRCycle(a, http.MethodGet, path, nil)
+		assert.NoError(t, r.route(req)(req, res))
+		assert.Equal(t, body, rec.Body.String())
+	}
+
+	req, res, _ := fakeRRCycle(a, http.MethodGet, "/a/b/c/w", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "a", req.Param("r").Value().String())
+	assert.Nil(t, req.Param("p"))
+	assert.Nil(t, req.Param("q"))
+
+	req, res, _ = fakeRRCycle(a, http.MethodGet, "/b/c/c/y", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+}
+
+func TestRouterRoutes(t *testing.T) {
+	a := New()
+	h := func(req *Request, res *Response) error {
+		return res.WriteString("Foobar")
+	}
+
+	assert.Empty(t, a.Routes())
+
+	a.GET("/", h).Name = "root"
+	a.POST("/foo/:bar<int>/*", h).Metadata = map[string]interface{}{
+		"foo": "bar",
+	}
+
+	g := a.Group("/bar")
+	g.BATCH([]string{http.MethodGet, http.MethodHead}, "/:foo", h)
+
+	rs := a.Routes()
+	assert.Len(t, rs, 4)
+
+	assert.Equal(t, http.MethodGet, rs[0].Method)
+	assert.Equal(t, "/", rs[0].Path)
+	assert.Empty(t, rs[0].ParamNames)
+	assert.Empty(t, rs[0].GroupPrefix)
+	assert.Equal(t, "root", rs[0].Name)
+	assert.Nil(t, rs[0].Metadata)
+
+	assert.Equal(t, http.MethodPost, rs[1].Method)
+	assert.Equal(t, "/foo/:bar<int>/*", rs[1].Path)
+	assert.Equal(t, []string{"bar", "*"}, rs[1].ParamNames)
+	assert.Empty(t, rs[1].GroupPrefix)
+	assert.Empty(t, rs[1].Name)
+	assert.Equal(t, "bar", rs[1].Metadata["foo"])
+
+	assert.Equal(t, http.MethodGet, rs[2].Method)
+	assert.Equal(t, "/bar/:foo", rs[2].Path)
+	assert.Equal(t, []string{"foo"}, rs[2].ParamNames)
+	assert.Equal(t, "/bar", rs[2].GroupPrefix)
+
+	assert.Equal(t, http.MethodHead, rs[3].Method)
+	assert.Equal(t, "/bar/:foo", rs[3].Path)
+	assert.Equal(t, "/bar", rs[3].GroupPrefix)
+
+	rs[0] = nil
+	assert.NotNil(t, a.Routes()[0])
+}
+
+func TestRouterURL(t *testing.T) {
+	a := New()
+	r := a.router
+	h := func(req *Request, res *Response) error {
+		return res.WriteString("Foobar")
+	}
+
+	r.register(http.MethodGet, "/", h).Name = "root"
+	r.register(http.MethodGet, "/foo/:bar", h).Name = "foo"
+	r.register(http.MethodGet, "/:foo/:bar/*", h).Name = "foobar"
+	r.register(http.MethodGet, "/café", h).Name = "cafe"
+
+	assert.Equal(t, "/", a.URL("root", nil))
+	assert.Equal(t, "/foo/bar", a.URL("foo", map[string]interface{}{
+		"bar": "bar",
+	}))
+	assert.Equal(t, "/foo/b%2Far", a.URL("foo", map[string]interface{}{
+		"bar": "b/ar",
+	}))
+	assert.Empty(t, a.URL("foo", nil))
+	assert.Empty(t, a.URL("foo", map[string]interface{}{
+		"bar": "",
+	}))
+	assert.Empty(t, a.URL("foo", map[string]interface{}{
+		"bar": nil,
+	}))
+	assert.Equal(
+		t,
+		"/1/b%20ar/foo%3F/bar",
+		a.URL("foobar", map[string]interface{}{
+			"foo": 1,
+			"bar": "b ar",
+			"*":   "foo?/bar",
+		}),
+	)
+	assert.Equal(t, "/caf%C3%A9", a.URL("cafe", nil))
+
+	r.register(http.MethodGet, "/users/:id<int>/:slug<[a-z]+>", h).Name =
+		"user"
+	assert.Equal(t, "/users/1/foo", a.URL("user", map[string]interface{}{
+		"id":   1,
+		"slug": "foo",
+	}))
+	assert.Empty(t, a.URL("barfoo", nil))
+
+	rt := a.GET("/bar/:foo", h)
+	assert.Equal(t, http.MethodGet, rt.Method)
+	assert.Equal(t, "/bar/:foo", rt.Path)
+	assert.Empty(t, rt.Name)
+
+	rt.Name = "bar"
+	assert.Equal(t, "/bar/foo", a.URL("bar", map[string]interface{}{
+		"foo": "foo",
+	}))
+
+	r.register(
+		http.MethodGet,
+		"/:lang?/docs/*path",
+		func(_ *Request, _ *Response) error {
+			return nil
+		},
+	).Name = "docs"
+	assert.Equal(t, "/docs/a/b", r.url("docs", map[string]interface{}{
+		"path": "a/b",
+	}))
+	assert.Equal(t, "/en/docs/a", r.url("docs", map[string]interface{}{
+		"lang": "en",
+		"path": "a",
+	}))
+}
+
+func TestRouterRemove(t *testing.T) {
+	a := New()
+	r := a.router
+
+	rt := r.register(
+		http.MethodGet,
+		"/foo/:bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foo/:bar]")
+		},
+	)
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/foo/bar", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "Matched [GET /foo/:bar]", rec.Body.String())
+
+	r.remove(rt)
+	r.remove(rt)
+	assert.Empty(t, r.routesSnapshot())
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo/bar", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, rec.Body.String())
+
+	r.register(
+		http.MethodGet,
+		"/foo/:bar",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foo/:bar] again")
+		},
+	)
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo/bar", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "Matched [GET /foo/:bar] again", rec.Body.String())
+}
+
+func TestRouterReplaceHandler(t *testing.T) {
+	a := New()
+	r := a.router
+
+	rt := r.register(
+		http.MethodGet,
+		"/foo",
+		func(_ *Request, res *Response) error {
+			return res.WriteString("Matched [GET /foo]")
+		},
+		func(next Handler) Handler {
+			return func(req *Request, res *Response) error {
+				res.Header.Set("Foo", "bar")
+				return next(req, res)
+			}
+		},
+	)
+
+	assert.PanicsWithValue(t, "air: route handler cannot be nil", func() {
+		r.replaceHandler(rt, nil)
+	})
+
+	r.replaceHandler(rt, func(_ *Request, res *Response) error {
+		return res.WriteString("Replaced [GET /foo]")
+	})
+
+	req, res, rec := fakeRRCycle(a, http.MethodGet, "/foo", nil)
+	assert.NoError(t, r.route(req)(req, res))
+	assert.Equal(t, "bar", rec.Header().Get("Foo"))
+	assert.Equal(t, "Replaced [GET /foo]", rec.Body.String())
+
+	r.remove(rt)
+	r.replaceHandler(rt, func(_ *Request, res *Response) error {
+		return res.WriteString("Replaced [GET /foo] again")
+	})
+
+	req, res, rec = fakeRRCycle(a, http.MethodGet, "/foo", nil)
+	assert.Error(t, r.route(req)(req, res), "Not Found")
+	assert.Equal(t, http.StatusNotFound, res.Status)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestRouterRouteConcurrently(t *testing.T) {
+	a := New()
+	r := a.router
+
+	h := func(_ *Request, res *Response) error {
+		return res.WriteString("Matched")
+	}
+
+	r.register(http.MethodGet, "/foo/:bar", h)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				req, res, _ := fakeRRCycle(
+					a,
+					http.MethodGet,
+					"/foo/bar",
+					nil,
+				)
+				r.route(req)(req, res)
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		p := fmt.Sprintf("/bar/%d/:baz", i)
+		rt := r.register(http.MethodGet, p, h)
+		r.replaceHandler(rt, h)
+		r.remove(rt)
+	}
+
+	wg.Wait()
+	assert.Len(t, r.routesSnapshot(), 1)
+}
+
 func TestRouteNodeChild(t *testing.T) {
 	n := &routeNode{}
-	n.children = append(n.children, &routeNode{
+	n.addChild(&routeNode{
 		label: 'a',
 		nType: routeNodeTypeStatic,
 	})
+	n.addChild(&routeNode{
+		label: ':',
+		nType: routeNodeTypeParam,
+	})
 
-	assert.NotNil(t, n.child('a', routeNodeTypeStatic))
-	assert.Nil(t, n.child('b', routeNodeTypeParam))
+	re := regexp.MustCompile("^(?:[0-9]+)$")
+	n.addChild(&routeNode{
+		label:       ':',
+		nType:       routeNodeTypeParam,
+		paramRegexp: re,
+	})
+
+	assert.NotNil(t, n.staticChild('a'))
+	assert.Nil(t, n.staticChild('b'))
 
 	assert.NotNil(t, n.childByLabel('a'))
 	assert.Nil(t, n.childByLabel('b'))
+	assert.NotNil(t, n.childByLabel(':'))
+	assert.Nil(t, n.childByLabel('*'))
+
+	assert.Len(t, n.paramChildren, 2)
+	assert.Equal(t, re, n.paramChildren[0].paramRegexp)
+	assert.Equal(t, n.paramChildren[0], n.paramChildByRegexp(re))
+	assert.Nil(t, n.paramChildByRegexp(nil).paramRegexp)
 
-	assert.NotNil(t, n.childByType(routeNodeTypeStatic))
-	assert.Nil(t, n.childByType(routeNodeTypeParam))
+	n.addChild(&routeNode{
+		label: '*',
+		nType: routeNodeTypeAny,
+	})
+	assert.NotNil(t, n.childByLabel('*'))
+	assert.Len(t, n.children(), 4)
+}
+
+func benchmarkRouterRoute(b *testing.B, routes, paths []string) {
+	a := New()
+	r := a.router
+
+	h := func(_ *Request, _ *Response) error {
+		return nil
+	}
+
+	for _, route := range routes {
+		r.register(http.MethodGet, route, h)
+	}
+
+	reqs := make([]*Request, 0, len(paths))
+	for _, path := range paths {
+		req, _, _ := fakeRRCycle(a, http.MethodGet, path, nil)
+		reqs = append(reqs, req)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, req := range reqs {
+			r.route(req)
+			if req.routeParamValues != nil {
+				r.routeParamValuesPool.Put(req.routeParamValues)
+				req.routeParamValues = nil
+			}
+		}
+	}
+}
+
+var benchmarkRouterRoutes = []string{
+	"/",
+	"/users",
+	"/users/new",
+	"/users/:id",
+	"/users/:id/edit",
+	"/users/:id/posts",
+	"/users/:id/posts/:pid",
+	"/users/:id/posts/:pid/comments/*",
+	"/posts/:slug<[a-z0-9-]+>",
+	"/posts/:id<int>",
+	"/static/*",
+	"/foo/:bar",
+	"/foo:bar",
+	"/:foo/:bar",
+	"/foobar*",
+	"/foobar/*",
+	"/foo/:bar/*",
+	"/foo:bar/*",
+	"/:foo/:bar/*",
+}
+
+func BenchmarkRouterRouteStatic(b *testing.B) {
+	benchmarkRouterRoute(b, benchmarkRouterRoutes, []string{
+		"/",
+		"/users",
+		"/users/new",
+	})
+}
+
+func BenchmarkRouterRouteParam(b *testing.B) {
+	benchmarkRouterRoute(b, benchmarkRouterRoutes, []string{
+		"/users/1",
+		"/users/1/edit",
+		"/users/1/posts/2",
+		"/posts/foo-bar",
+		"/posts/1",
+	})
+}
+
+func BenchmarkRouterRouteAny(b *testing.B) {
+	benchmarkRouterRoute(b, benchmarkRouterRoutes, []string{
+		"/static/foo/bar.css",
+		"/users/1/posts/2/comments/3/4",
+	})
+}
+
+func BenchmarkRouterRouteMix(b *testing.B) {
+	benchmarkRouterRoute(b, benchmarkRouterRoutes, []string{
+		"/foobar",
+		"/foo/bar",
+		"/fooobar",
+		"/bar/foo",
+		"/foobarfoobar",
+		"/foobar/foobar",
+		"/foo/bar/foobar",
+		"/foofoobar/foobar",
+		"/bar/foo/foobar",
+	})
+}
+
+func BenchmarkRouterRouteBacktracking(b *testing.B) {
+	benchmarkRouterRoute(b, []string{
+		"/a/b/c/x",
+		"/a/:p/c/y",
+		"/a/b/:q/z",
+		"/:r/b/c/w",
+		"/:r/b/*",
+	}, []string{
+		"/a/b/c/x",
+		"/a/b/c/y",
+		"/a/b/c/z",
+		"/a/b/c/w",
+		"/a/b/c/v",
+	})
 }
@@ -253,11 +253,16 @@ func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	req.SetHTTPRequest(r)
 	req.res = res
 	req.params = req.params[:0]
+	req.route = nil
+	req.routePattern = ""
 	req.routeParamNames = nil
 	req.routeParamValues = nil
 	req.parseRouteParamsOnce = &sync.Once{}
 	req.parseOtherParamsOnce = &sync.Once{}
+	req.otherParamsError = nil
+	req.multipartReaderUsed = false
 	req.localizedString = nil
+	req.mountMaxBodyBytes = 0
 
 	// Reset the response.
 
@@ -283,6 +288,7 @@ func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 
 	h := func(req *Request, res *Response) error {
 		rh := s.a.router.route(req)
+		rh = s.a.router.wrapGroupGases(req, rh)
 		h := func(req *Request, res *Response) error {
 			err := rh(req, res)
 			if res.Written {
@@ -315,7 +321,7 @@ func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	// Execute the chain.
 
 	if err := h(req, res); err != nil {
-		s.a.ErrorHandler(err, req, res)
+		s.a.errorHandler(req)(err, req, res)
 	}
 
 	// Execute the deferred functions.
@@ -0,0 +1,348 @@
+package air
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// validator is a validator that validates structs based on the validate tags.
+type validator struct {
+	a *Air
+}
+
+// newValidator returns a new instance of the `validator` with the a.
+func newValidator(a *Air) *validator {
+	return &validator{
+		a: a,
+	}
+}
+
+// validate validates the v based on the validate tags of its fields. It returns
+// a `*ValidationError` if any of the fields fails to validate.
+func (vr *validator) validate(v interface{}) error {
+	ve := &ValidationError{}
+	if err := vr.validateValue(reflect.ValueOf(v), "", ve); err != nil {
+		return err
+	} else if len(ve.FieldErrors) > 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// validateValue validates the v whose path is the path into the ve. The
+// structs, and the structs in the slices, the arrays and the maps are validated
+// recursively.
+func (vr *validator) validateValue(
+	v reflect.Value,
+	path string,
+	ve *ValidationError,
+) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+	case reflect.Slice, reflect.Array:
+		if !validatableType(v.Type().Elem()) {
+			return nil
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			err := vr.validateValue(
+				v.Index(i),
+				fmt.Sprintf("%s[%d]", path, i),
+				ve,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		if !validatableType(v.Type().Elem()) {
+			return nil
+		}
+
+		for _, k := range v.MapKeys() {
+			err := vr.validateValue(
+				v.MapIndex(k),
+				fmt.Sprintf("%s[%v]", path, k.Interface()),
+				ve,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		if tf.PkgPath != "" {
+			continue
+		}
+
+		fn := validationFieldName(tf)
+		fp := fn
+		if path != "" {
+			fp = path + "." + fn
+		}
+
+		vf := v.Field(i)
+		if tag := tf.Tag.Get("validate"); tag != "" && tag != "-" {
+			err := vr.validateField(vf, fn, fp, tag, ve)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := vr.validateValue(vf, fp, ve); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validationFieldName returns the name of the tf in the `ValidationFieldError`.
+// It is taken from the first tag of the `validationFieldNameTags` that names
+// the tf, so that it matches the name the clients know. Otherwise, it is the
+// Go name of the tf.
+func validationFieldName(tf reflect.StructField) string {
+	for _, k := range validationFieldNameTags {
+		n := tf.Tag.Get(k)
+		if i := strings.IndexByte(n, ','); i >= 0 {
+			n = n[:i]
+		}
+
+		if n != "" && n != "-" {
+			return n
+		}
+	}
+
+	return tf.Name
+}
+
+// validationFieldNameTags is the tag keys that name the fields in the
+// `ValidationFieldError`s in order of precedence.
+var validationFieldNameTags = []string{
+	"json",
+	"param",
+	"query",
+	"header",
+	"cookie",
+}
+
+// validatableType reports whether the values of the t may contain structs to be
+// validated.
+func validatableType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct,
+		reflect.Interface,
+		reflect.Slice,
+		reflect.Array,
+		reflect.Map:
+		return true
+	}
+
+	return false
+}
+
+// validateField validates the v of the field named with the name whose path is
+// the path based on the tag into the ve. Only the first failing rule of the tag
+// is recorded.
+func (vr *validator) validateField(
+	v reflect.Value,
+	name string,
+	path string,
+	tag string,
+	ve *ValidationError,
+) error {
+	for _, r := range strings.Split(tag, ",") {
+		rule, param := strings.TrimSpace(r), ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			rule, param = rule[:i], rule[i+1:]
+		}
+
+		if rule == "" {
+			continue
+		}
+
+		isZero := v.IsZero()
+		if rule == "omitempty" {
+			if isZero {
+				return nil
+			}
+
+			continue
+		} else if rule == "required" {
+			if !isZero {
+				continue
+			}
+		} else if v.Kind() == reflect.Ptr && v.IsNil() {
+			continue
+		} else if ok, err := validateRule(
+			reflect.Indirect(v),
+			rule,
+			param,
+		); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+
+		ve.FieldErrors = append(ve.FieldErrors, &ValidationFieldError{
+			Field: name,
+			Path:  path,
+			Rule:  rule,
+			Param: param,
+		})
+
+		return nil
+	}
+
+	return nil
+}
+
+// validateRule reports whether the v satisfies the rule with the param.
+func validateRule(v reflect.Value, rule, param string) (bool, error) {
+	switch rule {
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, fmt.Errorf(
+				"air: invalid validation rule param: %s=%s",
+				rule,
+				param,
+			)
+		}
+
+		var f float64
+		switch v.Kind() {
+		case reflect.Int,
+			reflect.Int8,
+			reflect.Int16,
+			reflect.Int32,
+			reflect.Int64:
+			f = float64(v.Int())
+		case reflect.Uint,
+			reflect.Uint8,
+			reflect.Uint16,
+			reflect.Uint32,
+			reflect.Uint64:
+			f = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			f = v.Float()
+		case reflect.String:
+			f = float64(utf8.RuneCountInString(v.String()))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			f = float64(v.Len())
+		default:
+			return false, fmt.Errorf(
+				"air: validation rule %s cannot be applied "+
+					"to %s",
+				rule,
+				v.Type(),
+			)
+		}
+
+		switch rule {
+		case "min":
+			return f >= n, nil
+		case "max":
+			return f <= n, nil
+		}
+
+		return f == n, nil
+	case "email":
+		if v.Kind() != reflect.String {
+			return false, errors.New(
+				"air: validation rule email can only be " +
+					"applied to strings",
+			)
+		}
+
+		a, err := mail.ParseAddress(v.String())
+
+		return err == nil && a.Address == v.String(), nil
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, o := range strings.Fields(param) {
+			if o == s {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	return false, fmt.Errorf("air: unknown validation rule: %s", rule)
+}
+
+// ValidationError is the error that lists the fields that fail to validate.
+type ValidationError struct {
+	// FieldErrors is the errors of the fields that fail to validate.
+	FieldErrors []*ValidationFieldError
+}
+
+// Error implements the `error`.
+func (ve *ValidationError) Error() string {
+	ss := make([]string, 0, len(ve.FieldErrors))
+	for _, fe := range ve.FieldErrors {
+		ss = append(ss, fe.Error())
+	}
+
+	return "air: validation failed: " + strings.Join(ss, "; ")
+}
+
+// ValidationFieldError is the error of a field that fails to validate.
+type ValidationFieldError struct {
+	// Field is the name of the field. It is taken from the json, param,
+	// query, header or cookie tag of the field in order, and falls back to
+	// the Go name of the field.
+	Field string
+
+	// Path is the path of the field from the top-level struct. Example:
+	// "users[0].email".
+	Path string
+
+	// Rule is the failing rule of the field. Example: "min".
+	Rule string
+
+	// Param is the param of the `Rule`. Example: "1".
+	Param string
+}
+
+// Error implements the `error`.
+func (vfe *ValidationFieldError) Error() string {
+	if vfe.Param != "" {
+		return fmt.Sprintf(
+			"%s does not satisfy %s=%s",
+			vfe.Path,
+			vfe.Rule,
+			vfe.Param,
+		)
+	}
+
+	return fmt.Sprintf("%s does not satisfy %s", vfe.Path, vfe.Rule)
+}
@@ -0,0 +1,137 @@
+package air
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewValidator(t *testing.T) {
+	a := New()
+	vr := a.validator
+
+	assert.NotNil(t, vr)
+	assert.NotNil(t, vr.a)
+}
+
+func TestValidatorValidate(t *testing.T) {
+	a := New()
+	vr := a.validator
+
+	type address struct {
+		City string `json:"city" validate:"required"`
+	}
+
+	type user struct {
+		Name      string   `json:"name" validate:"required,min=1,max=8"`
+		Email     string   `param:"email" validate:"omitempty,email"`
+		Role      string   `json:"role" validate:"oneof=admin user"`
+		Age       int      `json:"-" header:"age" validate:"min=18"`
+		Tags      []string `json:"tags,omitempty" validate:"max=2"`
+		Nickname  *string  `validate:"min=2"`
+		Code      string   `query:"code" validate:"len=4"`
+		Address   address
+		Addresses []*address        `json:"addrs" validate:"required"`
+		Labels    map[string]string `validate:"-"`
+	}
+
+	assert.NoError(t, vr.validate(nil))
+	assert.NoError(t, vr.validate("foobar"))
+	assert.NoError(t, vr.validate(&user{
+		Name:      "foo",
+		Role:      "admin",
+		Age:       18,
+		Code:      "abcd",
+		Address:   address{City: "foo"},
+		Addresses: []*address{{City: "bar"}},
+	}))
+
+	nickname := "f"
+	err := vr.validate(&user{
+		Name:      "foobarbaz",
+		Email:     "foobar",
+		Role:      "root",
+		Age:       17,
+		Tags:      []string{"a", "b", "c"},
+		Nickname:  &nickname,
+		Code:      "abc",
+		Addresses: []*address{{}},
+	})
+	assert.Error(t, err)
+
+	ve, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Len(t, ve.FieldErrors, 9)
+
+	paths := make([]string, 0, len(ve.FieldErrors))
+	for _, fe := range ve.FieldErrors {
+		paths = append(paths, fe.Path+":"+fe.Rule)
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			"name:max",
+			"email:email",
+			"role:oneof",
+			"age:min",
+			"tags:max",
+			"Nickname:min",
+			"code:len",
+			"Address.city:required",
+			"addrs[0].city:required",
+		},
+		paths,
+	)
+	assert.Equal(t, "city", ve.FieldErrors[7].Field)
+	assert.Equal(t, "8", ve.FieldErrors[0].Param)
+	assert.Equal(
+		t,
+		"air: validation failed: name does not satisfy max=8; "+
+			"email does not satisfy email",
+		(&ValidationError{FieldErrors: ve.FieldErrors[:2]}).Error(),
+	)
+
+	type invalid struct {
+		Foo string `validate:"foobar"`
+	}
+
+	err = vr.validate(&invalid{})
+	assert.Error(t, err)
+	_, ok = err.(*ValidationError)
+	assert.False(t, ok)
+}
+
+func TestRequestBindValidation(t *testing.T) {
+	a := New()
+
+	type foobar struct {
+		Foo string `json:"foo" validate:"required"`
+	}
+
+	req, res, _ := fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"bar":"foo"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+
+	err := req.Bind(&foobar{})
+	assert.Error(t, err)
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Status)
+
+	req, res, _ = fakeRRCycle(
+		a,
+		http.MethodPost,
+		"/",
+		strings.NewReader(`{"foo":"bar"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+
+	assert.NoError(t, req.Bind(&foobar{}))
+	assert.Equal(t, http.StatusOK, res.Status)
+}